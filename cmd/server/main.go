@@ -1,17 +1,54 @@
 package main
 
 import (
+	"context"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/ToxicSozo/GoDraw/internal/audit"
+	"github.com/ToxicSozo/GoDraw/internal/auth"
 	"github.com/ToxicSozo/GoDraw/internal/httpserver"
+	"github.com/ToxicSozo/GoDraw/internal/idempotency"
+	"github.com/ToxicSozo/GoDraw/internal/metrics"
+	"github.com/ToxicSozo/GoDraw/internal/reminder"
 	"github.com/ToxicSozo/GoDraw/internal/store"
+	"github.com/ToxicSozo/GoDraw/internal/stream"
+	"github.com/ToxicSozo/GoDraw/internal/webhook"
 )
 
 func main() {
-	st := store.New()
-	handler := httpserver.New(st)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	orgs := store.NewOrgs(storeOptions()...)
+
+	webhooks := webhook.New(webhook.Config{})
+	webhooksDone := make(chan struct{})
+	go func() {
+		defer close(webhooksDone)
+		webhooks.Run(ctx)
+	}()
+
+	events := stream.New(stream.Config{})
+
+	handler := httpserver.New(orgs, serverOptions(logger, webhooks, events)...)
+
+	scheduler := reminder.New(orgs, handler.ReminderNotifier(reminder.LogNotifier), reminderConfig())
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		scheduler.Run(ctx)
+	}()
 
 	srv := &http.Server{
 		Addr:         ":8080",
@@ -20,9 +57,128 @@ func main() {
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	srv.RegisterOnShutdown(events.Close)
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("shutdown: %v", err)
+		}
+	}()
 
 	log.Printf("starting reviewer service on %s", srv.Addr)
 	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("server stopped: %v", err)
 	}
+
+	stop()
+	<-schedulerDone
+	<-webhooksDone
+	log.Printf("reviewer service stopped")
+}
+
+func serverOptions(logger *slog.Logger, webhooks *webhook.Dispatcher, events *stream.Broker) []httpserver.Option {
+	opts := []httpserver.Option{
+		httpserver.WithWebhooks(webhooks),
+		httpserver.WithEventStream(events),
+		httpserver.WithAudit(audit.New(nil)),
+		httpserver.WithMetrics(metrics.NewRegistry()),
+		httpserver.WithLogger(logger),
+		httpserver.WithIdempotency(idempotency.New(idempotency.Config{
+			TTL: envDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		})),
+	}
+	for org, secret := range envCredentials("GITHUB_WEBHOOK_SECRET") {
+		opts = append(opts, httpserver.WithGitHubSecret(org, secret))
+	}
+	for org, token := range envCredentials("GITLAB_WEBHOOK_TOKEN") {
+		opts = append(opts, httpserver.WithGitLabToken(org, token))
+	}
+	if spec := os.Getenv("AUTH_TOKENS"); spec != "" {
+		tokens, err := auth.ParseTokens(spec)
+		if err != nil {
+			log.Fatalf("invalid AUTH_TOKENS: %v", err)
+		}
+		opts = append(opts, httpserver.WithAuth(tokens))
+	}
+	return opts
+}
+
+func storeOptions() []store.Option {
+	var opts []store.Option
+	if mode := os.Getenv("REVIEWER_SELECTION_MODE"); mode != "" {
+		if mode != store.SelectionRandom && mode != store.SelectionDeterministic {
+			log.Fatalf("unknown REVIEWER_SELECTION_MODE %q", mode)
+		}
+		opts = append(opts, store.WithSelectionMode(mode))
+	}
+	if raw := os.Getenv("REVIEWER_SEED"); raw != "" {
+		seed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			log.Fatalf("invalid REVIEWER_SEED: %v", err)
+		}
+		opts = append(opts, store.WithSeed(seed))
+	}
+	if raw := os.Getenv("REVIEWER_MAX_OPEN_REVIEWS"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			log.Fatalf("invalid REVIEWER_MAX_OPEN_REVIEWS %q", raw)
+		}
+		opts = append(opts, store.WithMaxOpenReviews(limit))
+	}
+	sla := envDuration("REVIEW_SLA", store.DefaultReviewSLA)
+	if sla <= 0 {
+		log.Fatalf("invalid REVIEW_SLA %s: must be positive", sla)
+	}
+	return append(opts, store.WithDefaultReviewSLA(sla))
+}
+
+func reminderConfig() reminder.Config {
+	return reminder.Config{
+		Interval:      envDuration("REMINDER_INTERVAL", 5*time.Minute),
+		RemindAfter:   envDuration("REMINDER_AFTER", 24*time.Hour),
+		EscalateAfter: envDuration("REMINDER_ESCALATE_AFTER", 48*time.Hour),
+		ReassignAfter: envDuration("REMINDER_REASSIGN_AFTER", 0),
+	}
+}
+
+// envCredentials parses comma-separated "secret[:org]" entries; a secret
+// without an organization belongs to the default one. A secret may serve only
+// one organization, so a delivery cannot be replayed into another.
+func envCredentials(name string) map[string]string {
+	byOrg := make(map[string]string)
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(os.Getenv(name), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		secret, org, _ := strings.Cut(entry, ":")
+		if org == "" {
+			org = store.DefaultOrg
+		}
+		if secret == "" || seen[secret] {
+			log.Fatalf("invalid %s: empty or repeated secret", name)
+		}
+		if _, ok := byOrg[org]; ok {
+			log.Fatalf("invalid %s: organization %s is listed twice", name, org)
+		}
+		seen[secret] = true
+		byOrg[org] = secret
+	}
+	return byOrg
+}
+
+func envDuration(name string, fallback time.Duration) time.Duration {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", name, err)
+	}
+	return d
 }
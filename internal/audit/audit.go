@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Entry is one recorded change. Actor is the authenticated user, anonymous or
+// system; ClaimedActor is the unverified name an unauthenticated client gave
+// itself, kept apart so that it is never mistaken for Actor.
+type Entry struct {
+	ID           uint64
+	Org          string
+	At           time.Time
+	Actor        string
+	ClaimedActor string
+	Operation    string
+	EntityType   string
+	EntityID     string
+	Status       int
+	Payload      json.RawMessage
+	Before       json.RawMessage
+	After        json.RawMessage
+}
+
+// Filter narrows a query. Org must always match; other zero fields match
+// everything. From and To bound the entry time inclusively.
+type Filter struct {
+	Org      string
+	EntityID string
+	Actor    string
+	From     time.Time
+	To       time.Time
+}
+
+func (f Filter) match(e Entry) bool {
+	if e.Org != f.Org {
+		return false
+	}
+	if f.EntityID != "" && e.EntityID != f.EntityID {
+		return false
+	}
+	if f.Actor != "" && e.Actor != f.Actor {
+		return false
+	}
+	if !f.From.IsZero() && e.At.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && e.At.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// Log is an append-only, in-memory audit trail.
+type Log struct {
+	mu      sync.RWMutex
+	now     func() time.Time
+	entries []Entry
+}
+
+func New(now func() time.Time) *Log {
+	if now == nil {
+		now = time.Now
+	}
+	return &Log{now: now}
+}
+
+// Record appends e, assigning its ID and, when unset, its timestamp.
+func (l *Log) Record(e Entry) Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e.ID = uint64(len(l.entries)) + 1
+	if e.At.IsZero() {
+		e.At = l.now().UTC()
+	}
+	l.entries = append(l.entries, e)
+	return e
+}
+
+// Query returns matching entries in the order they were recorded.
+func (l *Log) Query(f Filter) []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	result := make([]Entry, 0)
+	for _, e := range l.entries {
+		if f.match(e) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleLead   Role = "lead"
+	RoleMember Role = "member"
+	RoleBot    Role = "bot"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrInvalidRole  = errors.New("invalid role")
+)
+
+func ParseRole(raw string) (Role, error) {
+	switch role := Role(raw); role {
+	case RoleAdmin, RoleLead, RoleMember, RoleBot:
+		return role, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidRole, raw)
+}
+
+// Principal is the identity behind a token. For bots UserID names the bot
+// and need not exist in the store. Org is the organization the token is
+// confined to; empty means the default one.
+type Principal struct {
+	Org    string
+	UserID string
+	Role   Role
+}
+
+// Registry maps bearer tokens to principals. Tokens are kept only as
+// SHA-256 digests.
+type Registry struct {
+	mu     sync.RWMutex
+	tokens map[string]Principal
+}
+
+func NewRegistry() *Registry {
+	return &Registry{tokens: make(map[string]Principal)}
+}
+
+// ParseTokens builds a registry from a comma-separated list of
+// "token:user_id:role[:org]" entries.
+func ParseTokens(spec string) (*Registry, error) {
+	r := NewRegistry()
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) < 3 || len(parts) > 4 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid token entry %q", entry)
+		}
+		role, err := ParseRole(parts[2])
+		if err != nil {
+			return nil, err
+		}
+		p := Principal{UserID: parts[1], Role: role}
+		if len(parts) == 4 {
+			p.Org = parts[3]
+		}
+		r.Add(parts[0], p)
+	}
+	return r, nil
+}
+
+func (r *Registry) Add(token string, p Principal) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[digest(token)] = p
+}
+
+// Issue creates a random token for p.
+func (r *Registry) Issue(p Principal) string {
+	buf := make([]byte, 24)
+	_, _ = rand.Read(buf)
+	token := hex.EncodeToString(buf)
+	r.Add(token, p)
+	return token
+}
+
+func (r *Registry) Revoke(token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := digest(token)
+	if _, ok := r.tokens[key]; !ok {
+		return ErrInvalidToken
+	}
+	delete(r.tokens, key)
+	return nil
+}
+
+func (r *Registry) Lookup(token string) (Principal, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.tokens[digest(token)]
+	if !ok {
+		return Principal{}, ErrInvalidToken
+	}
+	return p, nil
+}
+
+func digest(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type contextKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,304 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/ToxicSozo/GoDraw/internal/audit"
+	"github.com/ToxicSozo/GoDraw/internal/auth"
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+const (
+	entityTeam        = "team"
+	entityUser        = "user"
+	entityPullRequest = "pull_request"
+	entityWebhook     = "webhook"
+)
+
+const (
+	actorHeader    = "X-Actor-ID"
+	actorAnonymous = "anonymous"
+	actorSystem    = "system"
+)
+
+const maxAuditPayload = 2048
+
+// sensitiveFields are replaced before a request payload is stored.
+var sensitiveFields = []string{"secret", "token"}
+
+type auditTarget struct {
+	entityType string
+	entityID   func(body []byte) string
+	// actor overrides the request actor, e.g. for forge integrations.
+	actor string
+}
+
+type auditEntryPayload struct {
+	ID           uint64          `json:"id"`
+	At           string          `json:"at"`
+	Actor        string          `json:"actor"`
+	ClaimedActor string          `json:"claimed_actor,omitempty"`
+	Operation    string          `json:"operation"`
+	EntityType   string          `json:"entity_type"`
+	EntityID     string          `json:"entity_id,omitempty"`
+	Status       int             `json:"status,omitempty"`
+	Payload      json.RawMessage `json:"payload,omitempty"`
+	Before       json.RawMessage `json:"before,omitempty"`
+	After        json.RawMessage `json:"after,omitempty"`
+}
+
+type auditResponse struct {
+	Entries []auditEntryPayload `json:"entries"`
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// audited records every call of a mutating handler with the affected
+// entity's state before and after it ran.
+func (s *Server) audited(operation string, target auditTarget, next http.HandlerFunc) http.HandlerFunc {
+	if s.audit == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			next(w, r)
+			return
+		}
+
+		body, err := bufferBody(w, r)
+		if err != nil {
+			badRequest(w, "cannot read payload")
+			return
+		}
+
+		entityID := ""
+		if target.entityID != nil {
+			entityID = target.entityID(body)
+		}
+		before := s.snapshot(r, target.entityType, entityID)
+
+		rec := &statusRecorder{ResponseWriter: w}
+		next(rec, r)
+
+		actor, claimed := target.actor, ""
+		if actor == "" {
+			actor, claimed = requestActor(r), claimedActor(r)
+		}
+
+		s.recordAudit(r, audit.Entry{
+			Org:          requestOrg(r),
+			Actor:        actor,
+			ClaimedActor: claimed,
+			Operation:    operation,
+			EntityType:   target.entityType,
+			EntityID:     entityID,
+			Status:       rec.status,
+			Payload:      summarizePayload(body),
+			Before:       before,
+			After:        s.snapshot(r, target.entityType, entityID),
+		})
+	}
+}
+
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := audit.Filter{
+		Org:      requestOrg(r),
+		EntityID: q.Get("entity_id"),
+		Actor:    q.Get("actor"),
+	}
+	for name, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		raw := q.Get(name)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			badRequest(w, name+" must be an RFC 3339 timestamp")
+			return
+		}
+		*dst = t
+	}
+
+	entries := s.audit.Query(filter)
+	resp := auditResponse{Entries: make([]auditEntryPayload, 0, len(entries))}
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, auditEntryPayload{
+			ID:           e.ID,
+			At:           e.At.Format(time.RFC3339Nano),
+			Actor:        e.Actor,
+			ClaimedActor: e.ClaimedActor,
+			Operation:    e.Operation,
+			EntityType:   e.EntityType,
+			EntityID:     e.EntityID,
+			Status:       e.Status,
+			Payload:      e.Payload,
+			Before:       e.Before,
+			After:        e.After,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// auditStoreEvent records mutations the service makes on its own, which do
+// not pass through an audited handler.
+func (s *Server) auditStoreEvent(e store.Event) {
+	if s.audit == nil {
+		return
+	}
+	reassigned, ok := e.(store.ReviewerReassigned)
+	if !ok || !reassigned.Automatic {
+		return
+	}
+
+	before := *reassigned.PR
+	before.AssignedReviewers = append([]string(nil), reassigned.PR.AssignedReviewers...)
+	if i := reviewerIndexOf(before.AssignedReviewers, reassigned.NewReviewerID); i != -1 {
+		before.AssignedReviewers[i] = reassigned.OldReviewerID
+	}
+
+	payload, _ := json.Marshal(map[string]string{
+		"pull_request_id": reassigned.PR.ID,
+		"old_user_id":     reassigned.OldReviewerID,
+	})
+	s.audit.Record(audit.Entry{
+		Org:        reassigned.Org,
+		At:         reassigned.At,
+		Actor:      actorSystem,
+		Operation:  "pullRequest.autoReassign",
+		EntityType: entityPullRequest,
+		EntityID:   reassigned.PR.ID,
+		Payload:    payload,
+		Before:     marshalRaw(makePullRequestResponse(&before)),
+		After:      marshalRaw(makePullRequestResponse(reassigned.PR)),
+	})
+}
+
+func (s *Server) snapshot(r *http.Request, entityType, id string) json.RawMessage {
+	if id == "" {
+		return nil
+	}
+
+	switch entityType {
+	case entityTeam:
+		if team, err := s.tenant(r).GetTeam(id); err == nil {
+			return marshalRaw(makeTeamPayload(team))
+		}
+	case entityUser:
+		if user, err := s.tenant(r).GetUser(id); err == nil {
+			return marshalRaw(makeUserPayload(user))
+		}
+	case entityPullRequest:
+		if pr, err := s.tenant(r).GetPullRequest(id); err == nil {
+			return marshalRaw(makePullRequestResponse(pr))
+		}
+	}
+	return nil
+}
+
+// requestActor is the authenticated principal, or anonymous without one.
+func requestActor(r *http.Request) string {
+	if p, ok := auth.FromContext(r.Context()); ok {
+		return p.UserID
+	}
+	return actorAnonymous
+}
+
+// claimedActor is the self-reported actor header of an unauthenticated
+// request. It is kept for reference only, since any client can set it.
+func claimedActor(r *http.Request) string {
+	if _, ok := auth.FromContext(r.Context()); ok {
+		return ""
+	}
+	return r.Header.Get(actorHeader)
+}
+
+func jsonField(name string) func([]byte) string {
+	return func(body []byte) string {
+		var fields map[string]any
+		if json.Unmarshal(body, &fields) != nil {
+			return ""
+		}
+		value, _ := fields[name].(string)
+		return value
+	}
+}
+
+// summarizePayload redacts secrets and caps the stored size of a request
+// body. Bodies that are not JSON objects are dropped.
+func summarizePayload(body []byte) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil {
+		return nil
+	}
+	for _, name := range sensitiveFields {
+		if _, ok := fields[name]; ok {
+			fields[name] = json.RawMessage(`"[redacted]"`)
+		}
+	}
+
+	summary := marshalRaw(fields)
+	if len(summary) <= maxAuditPayload {
+		return summary
+	}
+
+	// Large bodies such as forge webhooks keep only their top-level scalars.
+	for name, value := range fields {
+		if len(value) > 0 && (value[0] == '{' || value[0] == '[') {
+			delete(fields, name)
+		}
+	}
+	fields["truncated"] = json.RawMessage("true")
+	summary = marshalRaw(fields)
+	if len(summary) > maxAuditPayload {
+		return marshalRaw(map[string]any{"truncated": true, "size": len(body)})
+	}
+	return summary
+}
+
+func marshalRaw(v any) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func reviewerIndexOf(reviewers []string, id string) int {
+	for i, reviewer := range reviewers {
+		if reviewer == id {
+			return i
+		}
+	}
+	return -1
+}
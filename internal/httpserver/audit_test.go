@@ -0,0 +1,66 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/ToxicSozo/GoDraw/internal/audit"
+	"github.com/ToxicSozo/GoDraw/internal/auth"
+)
+
+func TestAuditActor(t *testing.T) {
+	tests := []struct {
+		name        string
+		withAuth    bool
+		headers     []string
+		wantActor   string
+		wantClaimed string
+	}{
+		{"anonymous", false, nil, "anonymous", ""},
+		{"claimed header is not the actor", false, []string{actorHeader, "u2"}, "anonymous", "u2"},
+		{"principal", true, []string{"Authorization", "Bearer " + adminToken}, "admin", ""},
+		{"principal ignores the header", true, []string{"Authorization", "Bearer " + adminToken, actorHeader, "u2"}, "admin", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []Option{WithAudit(audit.New(nil))}
+			if tt.withAuth {
+				tokens := auth.NewRegistry()
+				tokens.Add(adminToken, auth.Principal{UserID: "admin", Role: auth.RoleAdmin})
+				opts = append(opts, WithAuth(tokens))
+			}
+			s, _ := newTestServer(t, opts...)
+			mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend, tt.headers...)
+
+			admin := []string{"Authorization", "Bearer " + adminToken}
+			w := mustDo(t, s, http.StatusOK, http.MethodGet, "/audit", "", admin...)
+			var resp struct {
+				Entries []map[string]any `json:"entries"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode %q: %v", w.Body.String(), err)
+			}
+			if len(resp.Entries) != 1 {
+				t.Fatalf("entries = %v, want one", resp.Entries)
+			}
+			e := resp.Entries[0]
+			if e["actor"] != tt.wantActor {
+				t.Errorf("actor = %v, want %s", e["actor"], tt.wantActor)
+			}
+			claimed, _ := e["claimed_actor"].(string)
+			if claimed != tt.wantClaimed {
+				t.Errorf("claimed_actor = %q, want %q", claimed, tt.wantClaimed)
+			}
+
+			// The actor filter matches verified actors only.
+			w = mustDo(t, s, http.StatusOK, http.MethodGet, "/audit?actor=u2", "", admin...)
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode %q: %v", w.Body.String(), err)
+			}
+			if len(resp.Entries) != 0 {
+				t.Errorf("actor=u2 matched %v", resp.Entries)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ToxicSozo/GoDraw/internal/auth"
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+const entityToken = "token"
+
+// publicPaths authenticate callers on their own, e.g. by webhook signature.
+var publicPaths = map[string]bool{
+	"/openapi.json":           true,
+	"/integrations/github":    true,
+	"/integrations/gitlab":    true,
+	"/v1/integrations/github": true,
+	"/v1/integrations/gitlab": true,
+}
+
+// policy decides whether a non-admin principal may call a route. body is the
+// buffered request payload and is empty for GET requests.
+type policy func(r *http.Request, p auth.Principal, body []byte) bool
+
+type issueTokenRequest struct {
+	UserID string `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+type issueTokenResponse struct {
+	Token  string `json:"token"`
+	UserID string `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+type revokeTokenRequest struct {
+	Token string `json:"token"`
+}
+
+// WithAuth requires a bearer token from r on every non-public route and
+// enforces per-route role checks.
+func WithAuth(r *auth.Registry) Option {
+	return func(s *Server) {
+		s.tokens = r
+	}
+}
+
+// authenticate resolves the bearer token into a principal stored in the
+// request context. It reports false after writing a 401.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if s.tokens == nil || publicPaths[r.URL.Path] {
+		return r, true
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if ok {
+		if p, err := s.tokens.Lookup(strings.TrimSpace(token)); err == nil {
+			return r.WithContext(auth.WithPrincipal(r.Context(), p)), true
+		}
+	}
+
+	w.Header().Set("WWW-Authenticate", `Bearer realm="reviewer"`)
+	writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "missing or invalid bearer token")
+	return r, false
+}
+
+// authorized rejects principals that allow does not admit. Admins pass every
+// check.
+func (s *Server) authorized(allow policy, next http.HandlerFunc) http.HandlerFunc {
+	if s.tokens == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, _ := auth.FromContext(r.Context())
+		if p.Role == auth.RoleAdmin {
+			next(w, r)
+			return
+		}
+
+		var body []byte
+		if r.Method != http.MethodGet {
+			var err error
+			if body, err = bufferBody(w, r); err != nil {
+				badRequest(w, "cannot read payload")
+				return
+			}
+		}
+
+		if !allow(r, p, body) {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "not allowed for this token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func orgOf(p auth.Principal) string {
+	if p.Org == "" {
+		return store.DefaultOrg
+	}
+	return p.Org
+}
+
+func adminOnly(*http.Request, auth.Principal, []byte) bool { return false }
+
+func anyone(*http.Request, auth.Principal, []byte) bool { return true }
+
+// leadOrSelf admits the user named by field and leads of that user's team.
+func (s *Server) leadOrSelf(field string) policy {
+	return func(r *http.Request, p auth.Principal, body []byte) bool {
+		userID := jsonField(field)(body)
+		if userID == p.UserID {
+			return true
+		}
+		user, err := s.tenant(r).GetUser(userID)
+		return err == nil && s.isLead(r, p, user.TeamName)
+	}
+}
+
+// teamLead admits leads of the team named by the team_name field.
+func (s *Server) teamLead(r *http.Request, p auth.Principal, body []byte) bool {
+	return s.isLead(r, p, jsonField("team_name")(body))
+}
+
+// createPullRequest admits bots, the author and leads of the author's team.
+func (s *Server) createPullRequest(r *http.Request, p auth.Principal, body []byte) bool {
+	if p.Role == auth.RoleBot {
+		return true
+	}
+	return s.leadOrSelf("author_id")(r, p, body)
+}
+
+// managePullRequest admits bots, the author and leads of the author's team.
+// With reviewerField set, the reviewer it names may act on their own
+// assignment as well.
+func (s *Server) managePullRequest(reviewerField string) policy {
+	return func(r *http.Request, p auth.Principal, body []byte) bool {
+		if p.Role == auth.RoleBot {
+			return true
+		}
+		if reviewerField != "" && jsonField(reviewerField)(body) == p.UserID {
+			return true
+		}
+
+		pr, err := s.tenant(r).GetPullRequest(jsonField("pull_request_id")(body))
+		if err != nil {
+			// Let the handler report the missing PR.
+			return true
+		}
+		if pr.AuthorID == p.UserID {
+			return true
+		}
+		author, err := s.tenant(r).GetUser(pr.AuthorID)
+		return err == nil && s.isLead(r, p, author.TeamName)
+	}
+}
+
+// assignedReviewer admits a reviewer submitting their own review on a PR they
+// are assigned to.
+func (s *Server) assignedReviewer(r *http.Request, p auth.Principal, body []byte) bool {
+	if jsonField("user_id")(body) != p.UserID {
+		return false
+	}
+	pr, err := s.tenant(r).GetPullRequest(jsonField("pull_request_id")(body))
+	if err != nil {
+		return true
+	}
+	return reviewerIndexOf(pr.AssignedReviewers, p.UserID) != -1
+}
+
+// isLead reports whether p leads teamName: either as the team's configured
+// lead or through a lead token issued to one of its members.
+func (s *Server) isLead(r *http.Request, p auth.Principal, teamName string) bool {
+	if teamName == "" {
+		return false
+	}
+	if team, err := s.tenant(r).GetTeam(teamName); err == nil && team.LeadID == p.UserID {
+		return true
+	}
+	if p.Role != auth.RoleLead {
+		return false
+	}
+	user, err := s.tenant(r).GetUser(p.UserID)
+	return err == nil && user.TeamName == teamName
+}
+
+func (s *Server) handleIssueToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	var req issueTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "invalid JSON payload")
+		return
+	}
+
+	if req.UserID == "" || req.Role == "" {
+		badRequest(w, "user_id and role are required")
+		return
+	}
+
+	role, err := auth.ParseRole(req.Role)
+	if err != nil {
+		badRequest(w, "role must be one of admin, lead, member, bot")
+		return
+	}
+
+	// Bots act under their own name; everyone else must be a known user.
+	if role != auth.RoleBot {
+		if _, err := s.tenant(r).GetUser(req.UserID); err != nil {
+			if errors.Is(err, store.ErrUserNotFound) {
+				writeNotFound(w)
+				return
+			}
+			writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+			return
+		}
+	}
+
+	token := s.tokens.Issue(auth.Principal{Org: requestOrg(r), UserID: req.UserID, Role: role})
+	writeJSON(w, http.StatusCreated, issueTokenResponse{
+		Token:  token,
+		UserID: req.UserID,
+		Role:   string(role),
+	})
+}
+
+func (s *Server) handleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	var req revokeTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "invalid JSON payload")
+		return
+	}
+
+	if req.Token == "" {
+		badRequest(w, "token is required")
+		return
+	}
+
+	// Tokens of other organizations are reported as unknown.
+	if p, err := s.tokens.Lookup(req.Token); err == nil && orgOf(p) != requestOrg(r) {
+		writeNotFound(w)
+		return
+	}
+	if err := s.tokens.Revoke(req.Token); err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) {
+			writeNotFound(w)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// bufferBody reads the request payload and replaces r.Body so the next
+// handler can decode it again.
+func bufferBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -0,0 +1,147 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ToxicSozo/GoDraw/internal/auth"
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+// authFixture is team backend (u1..u4, configured lead u2) and team frontend
+// (u5, u6) with pr-1 by u1. It returns the reviewers of pr-1 and a backend
+// member that is not assigned.
+func authFixture(t *testing.T) (*Server, *auth.Registry, []string, string) {
+	t.Helper()
+	tokens := auth.NewRegistry()
+	s, orgs := newTestServer(t, WithAuth(tokens))
+	st := orgs.Get(store.DefaultOrg)
+
+	for name, ids := range map[string][]string{"backend": {"u1", "u2", "u3", "u4"}, "frontend": {"u5", "u6"}} {
+		members := make([]store.TeamMemberInput, 0, len(ids))
+		for _, id := range ids {
+			members = append(members, store.TeamMemberInput{UserID: id, Username: id, IsActive: true})
+		}
+		if _, err := st.CreateTeam(name, members); err != nil {
+			t.Fatalf("CreateTeam(%s): %v", name, err)
+		}
+	}
+	if _, err := st.SetTeamLead("backend", "u2"); err != nil {
+		t.Fatalf("SetTeamLead: %v", err)
+	}
+	pr, err := st.CreatePullRequest(store.CreatePullRequestInput{ID: "pr-1", Name: "pr-1", AuthorID: "u1"})
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	idle := ""
+	for _, id := range []string{"u2", "u3", "u4"} {
+		if reviewerIndexOf(pr.AssignedReviewers, id) == -1 {
+			idle = id
+		}
+	}
+	return s, tokens, pr.AssignedReviewers, idle
+}
+
+func TestPolicies(t *testing.T) {
+	s, _, reviewers, idle := authFixture(t)
+	// A reviewer who is not also the team's lead.
+	reviewer := reviewers[0]
+	if reviewer == "u2" {
+		reviewer = reviewers[1]
+	}
+	member := func(id string) auth.Principal { return auth.Principal{UserID: id, Role: auth.RoleMember} }
+	lead := func(id string) auth.Principal { return auth.Principal{UserID: id, Role: auth.RoleLead} }
+	bot := auth.Principal{UserID: "ci", Role: auth.RoleBot}
+
+	tests := []struct {
+		name  string
+		allow policy
+		p     auth.Principal
+		body  string
+		want  bool
+	}{
+		{"leadOrSelf: self", s.leadOrSelf("user_id"), member("u3"), `{"user_id":"u3"}`, true},
+		{"leadOrSelf: other member", s.leadOrSelf("user_id"), member("u3"), `{"user_id":"u1"}`, false},
+		{"leadOrSelf: configured lead", s.leadOrSelf("user_id"), member("u2"), `{"user_id":"u1"}`, true},
+		{"leadOrSelf: lead token of the team", s.leadOrSelf("user_id"), lead("u4"), `{"user_id":"u1"}`, true},
+		{"leadOrSelf: lead token of another team", s.leadOrSelf("user_id"), lead("u6"), `{"user_id":"u1"}`, false},
+		{"leadOrSelf: unknown user", s.leadOrSelf("user_id"), lead("u4"), `{"user_id":"nobody"}`, false},
+		{"leadOrSelf: bot", s.leadOrSelf("user_id"), bot, `{"user_id":"u1"}`, false},
+
+		{"managePullRequest: bot", s.managePullRequest(""), bot, `{"pull_request_id":"pr-1"}`, true},
+		{"managePullRequest: author", s.managePullRequest(""), member("u1"), `{"pull_request_id":"pr-1"}`, true},
+		{"managePullRequest: configured lead", s.managePullRequest(""), member("u2"), `{"pull_request_id":"pr-1"}`, true},
+		{"managePullRequest: lead token of another team", s.managePullRequest(""), lead("u6"), `{"pull_request_id":"pr-1"}`, false},
+		{"managePullRequest: reviewer without a reviewer field", s.managePullRequest(""), member(reviewer), `{"pull_request_id":"pr-1","old_user_id":"` + reviewer + `"}`, false},
+		{"managePullRequest: reviewer on their own assignment", s.managePullRequest("old_user_id"), member(reviewer), `{"pull_request_id":"pr-1","old_user_id":"` + reviewer + `"}`, true},
+		{"managePullRequest: member on another assignment", s.managePullRequest("old_user_id"), member("u5"), `{"pull_request_id":"pr-1","old_user_id":"` + reviewer + `"}`, false},
+		{"managePullRequest: missing PR is left to the handler", s.managePullRequest(""), member("u5"), `{"pull_request_id":"nope"}`, true},
+
+		{"assignedReviewer: own review", s.assignedReviewer, member(reviewer), `{"pull_request_id":"pr-1","user_id":"` + reviewer + `"}`, true},
+		{"assignedReviewer: not assigned", s.assignedReviewer, member(idle), `{"pull_request_id":"pr-1","user_id":"` + idle + `"}`, false},
+		{"assignedReviewer: on behalf of another", s.assignedReviewer, member("u1"), `{"pull_request_id":"pr-1","user_id":"` + reviewer + `"}`, false},
+		{"assignedReviewer: lead on behalf of a reviewer", s.assignedReviewer, lead("u4"), `{"pull_request_id":"pr-1","user_id":"` + reviewer + `"}`, false},
+		{"assignedReviewer: missing PR is left to the handler", s.assignedReviewer, member("u3"), `{"pull_request_id":"nope","user_id":"u3"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			if got := tt.allow(r, tt.p, []byte(tt.body)); got != tt.want {
+				t.Errorf("allowed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *auth.Principal
+		path string
+		body func(reviewer, idle string) string
+		want int
+	}{
+		{"no token", nil, "/users/setIsActive", func(string, string) string { return `{"user_id":"u3","is_active":false}` }, http.StatusUnauthorized},
+		{"member deactivates self", &auth.Principal{UserID: "u3", Role: auth.RoleMember}, "/users/setIsActive",
+			func(string, string) string { return `{"user_id":"u3","is_active":false}` }, http.StatusOK},
+		{"member deactivates another", &auth.Principal{UserID: "u3", Role: auth.RoleMember}, "/users/setIsActive",
+			func(string, string) string { return `{"user_id":"u4","is_active":false}` }, http.StatusForbidden},
+		{"admin passes every policy", &auth.Principal{UserID: "root", Role: auth.RoleAdmin}, "/team/setLead",
+			func(string, string) string { return `{"team_name":"backend","user_id":"u3"}` }, http.StatusOK},
+		{"lead cannot change the lead", &auth.Principal{UserID: "u2", Role: auth.RoleLead}, "/team/setLead",
+			func(string, string) string { return `{"team_name":"backend","user_id":"u3"}` }, http.StatusForbidden},
+		{"configured lead sets the SLA", &auth.Principal{UserID: "u2", Role: auth.RoleMember}, "/team/setReviewSLA",
+			func(string, string) string { return `{"team_name":"backend","sla_hours":8}` }, http.StatusOK},
+		{"reviewer submits own review", &auth.Principal{UserID: "", Role: auth.RoleMember}, "/pullRequest/review",
+			func(reviewer, _ string) string { return `{"pull_request_id":"pr-1","user_id":"` + reviewer + `"}` }, http.StatusOK},
+		{"unassigned member reviews", &auth.Principal{UserID: "", Role: auth.RoleMember}, "/pullRequest/review",
+			func(_, idle string) string { return `{"pull_request_id":"pr-1","user_id":"` + idle + `"}` }, http.StatusForbidden},
+		{"other team merges", &auth.Principal{UserID: "u5", Role: auth.RoleMember}, "/pullRequest/merge",
+			func(string, string) string { return `{"pull_request_id":"pr-1"}` }, http.StatusForbidden},
+		{"bot merges", &auth.Principal{UserID: "ci", Role: auth.RoleBot}, "/pullRequest/merge",
+			func(string, string) string { return `{"pull_request_id":"pr-1"}` }, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, tokens, reviewers, idle := authFixture(t)
+			body := tt.body(reviewers[0], idle)
+
+			var headers []string
+			if tt.p != nil {
+				p := *tt.p
+				if p.UserID == "" {
+					// The review cases act as the user the body names.
+					p.UserID = jsonField("user_id")([]byte(body))
+				}
+				tokens.Add("token", p)
+				headers = []string{"Authorization", "Bearer token"}
+			}
+
+			w := do(t, s, http.MethodPost, tt.path, body, headers...)
+			if w.Code != tt.want {
+				t.Errorf("status = %d %s, want %d", w.Code, w.Body.String(), tt.want)
+			}
+		})
+	}
+}
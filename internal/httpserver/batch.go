@@ -0,0 +1,236 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ToxicSozo/GoDraw/internal/audit"
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+const maxBatchOperations = 1000
+
+// batchPrefixes are the routes a batch may call: those backed by the
+// organization's store, so an atomic batch can roll all of them back.
+var batchPrefixes = []string{
+	"/team/", "/users/", "/pullRequest/",
+	"/v1/teams", "/v1/users/", "/v1/pull-requests",
+}
+
+var errBatchFailed = errors.New("batch operation failed")
+
+type batchRequest struct {
+	Atomic     bool             `json:"atomic"`
+	Operations []batchOperation `json:"operations"`
+}
+
+type batchOperation struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+type batchResult struct {
+	Index  int             `json:"index"`
+	Status int             `json:"status"`
+	ETag   string          `json:"etag,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+type batchResponse struct {
+	Committed bool          `json:"committed"`
+	Results   []batchResult `json:"results"`
+}
+
+// batchState is carried in the context of operations of an atomic batch.
+// They read and write tx, and their audit entries wait for the commit.
+type batchState struct {
+	tx    *store.Store
+	audit []audit.Entry
+}
+
+type batchContextKey struct{}
+
+// batchRecorder captures an operation's response instead of sending it.
+type batchRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *batchRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *batchRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *batchRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+// handleBatch runs a list of operations in order through the regular
+// handlers, so each one is authorized, validated and audited as if it were
+// sent on its own. An atomic batch stops at the first failed operation and
+// discards the changes of all of them.
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	var req batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "invalid JSON payload")
+		return
+	}
+
+	if len(req.Operations) == 0 {
+		badRequest(w, "operations are required")
+		return
+	}
+	if len(req.Operations) > maxBatchOperations {
+		badRequest(w, fmt.Sprintf("at most %d operations are allowed", maxBatchOperations))
+		return
+	}
+
+	resp := batchResponse{Results: make([]batchResult, 0, len(req.Operations))}
+	if !req.Atomic {
+		for i, op := range req.Operations {
+			resp.Results = append(resp.Results, s.runBatchOperation(r, i, op))
+		}
+		resp.Committed = true
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	state := &batchState{}
+	err := s.tenant(r).Transaction(func(tx *store.Store) error {
+		state.tx = tx
+		ctx := context.WithValue(r.Context(), batchContextKey{}, state)
+		for i, op := range req.Operations {
+			result := s.runBatchOperation(r.WithContext(ctx), i, op)
+			resp.Results = append(resp.Results, result)
+			if result.Status >= http.StatusBadRequest {
+				return errBatchFailed
+			}
+		}
+		return nil
+	})
+	switch {
+	case err == nil:
+		resp.Committed = true
+		if s.audit != nil {
+			for _, entry := range state.audit {
+				s.audit.Record(entry)
+			}
+		}
+	case !errors.Is(err, errBatchFailed):
+		writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// runBatchOperation sends one operation through the mux as a request of the
+// same caller and organization as parent.
+func (s *Server) runBatchOperation(parent *http.Request, index int, op batchOperation) batchResult {
+	rec := &batchRecorder{header: make(http.Header)}
+	rec.header.Set(requestIDHeader, requestID(parent))
+	s.serveBatchOperation(rec, parent, op)
+
+	result := batchResult{Index: index, Status: rec.status, ETag: rec.header.Get("ETag")}
+	if result.Status == 0 {
+		result.Status = http.StatusOK
+	}
+	if body := bytes.TrimSpace(rec.body.Bytes()); len(body) > 0 {
+		if json.Valid(body) {
+			result.Body = body
+		} else {
+			result.Body = marshalRaw(string(body))
+		}
+	}
+	return result
+}
+
+func (s *Server) serveBatchOperation(w http.ResponseWriter, parent *http.Request, op batchOperation) {
+	method := strings.ToUpper(op.Method)
+	if method == "" {
+		badRequest(w, "method is required")
+		return
+	}
+	if !batchable(op.Path) {
+		badRequest(w, "path is not allowed in a batch")
+		return
+	}
+
+	var body []byte
+	if method != http.MethodGet && len(op.Body) > 0 {
+		body = op.Body
+	}
+	r, err := http.NewRequestWithContext(parent.Context(), method, op.Path, bytes.NewReader(body))
+	if err != nil {
+		badRequest(w, "invalid path")
+		return
+	}
+
+	// The operation acts as the batch's caller; only its own conditions
+	// apply.
+	r.Header = parent.Header.Clone()
+	for _, name := range []string{"Content-Length", idempotencyHeader, "If-Match"} {
+		r.Header.Del(name)
+	}
+	r.Header.Set("Content-Type", "application/json")
+	for name, value := range op.Headers {
+		r.Header.Set(name, value)
+	}
+
+	if _, pattern := s.mux.Handler(r); pattern == "" {
+		writeNotFound(w)
+		return
+	}
+	if !s.validateBody(w, r) {
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func batchable(path string) bool {
+	path, _, _ = strings.Cut(path, "?")
+	for _, prefix := range batchPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			rest := path[len(prefix):]
+			if strings.HasSuffix(prefix, "/") || rest == "" || strings.HasPrefix(rest, "/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func batchFromContext(r *http.Request) (*batchState, bool) {
+	state, ok := r.Context().Value(batchContextKey{}).(*batchState)
+	return state, ok
+}
+
+// recordAudit stores entry, or holds it back until an atomic batch commits.
+func (s *Server) recordAudit(r *http.Request, entry audit.Entry) {
+	if state, ok := batchFromContext(r); ok {
+		state.audit = append(state.audit, entry)
+		return
+	}
+	s.audit.Record(entry)
+}
@@ -0,0 +1,125 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ToxicSozo/GoDraw/internal/audit"
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+type batchEnvelope struct {
+	Committed bool `json:"committed"`
+	Results   []struct {
+		Index  int             `json:"index"`
+		Status int             `json:"status"`
+		ETag   string          `json:"etag"`
+		Body   json.RawMessage `json:"body"`
+	} `json:"results"`
+}
+
+func TestBatch(t *testing.T) {
+	const (
+		createPR2 = `{"method":"POST","path":"/v1/pull-requests","body":{"pull_request_id":"pr-2","pull_request_name":"pr-2","author_id":"u1"}}`
+		mergePR1  = `{"method":"POST","path":"/v1/pull-requests/pr-1/merge"}`
+		setLead   = `{"method":"PUT","path":"/v1/teams/backend/lead","body":{"user_id":"u2"}}`
+		missing   = `{"method":"POST","path":"/pullRequest/merge","body":{"pull_request_id":"nope"}}`
+		stale     = `{"method":"PUT","path":"/v1/teams/backend/lead","headers":{"If-Match":"\"99\""},"body":{"user_id":"u3"}}`
+	)
+	tests := []struct {
+		name          string
+		atomic        bool
+		ops           []string
+		wantCommitted bool
+		wantStatuses  []int
+		wantPR2       bool
+		wantMerged    bool
+		wantLead      string
+		wantAudit     int
+	}{
+		{"atomic success", true, []string{createPR2, mergePR1, setLead}, true,
+			[]int{201, 200, 200}, true, true, "u2", 3},
+		{"atomic rolls back on 404", true, []string{createPR2, mergePR1, missing, setLead}, false,
+			[]int{201, 200, 404}, false, false, "", 0},
+		{"atomic rolls back on 412", true, []string{createPR2, setLead, stale}, false,
+			[]int{201, 200, 412}, false, false, "", 0},
+		{"atomic rolls back on a rejected path", true, []string{mergePR1, `{"method":"GET","path":"/metrics"}`}, false,
+			[]int{200, 400}, false, false, "", 0},
+		{"non-atomic keeps going", false, []string{createPR2, missing, mergePR1, stale}, true,
+			[]int{201, 404, 200, 412}, true, true, "", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := audit.New(nil)
+			s, orgs := newTestServer(t, WithAudit(log))
+			mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+			createPR(t, s, "pr-1")
+			audited := len(log.Query(audit.Filter{Org: store.DefaultOrg}))
+
+			body := fmt.Sprintf(`{"atomic":%v,"operations":[%s]}`, tt.atomic, strings.Join(tt.ops, ","))
+			w := mustDo(t, s, http.StatusOK, http.MethodPost, "/batch", body)
+			var resp batchEnvelope
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode %q: %v", w.Body.String(), err)
+			}
+
+			if resp.Committed != tt.wantCommitted {
+				t.Errorf("committed = %v, want %v", resp.Committed, tt.wantCommitted)
+			}
+			statuses := make([]int, 0, len(resp.Results))
+			for i, result := range resp.Results {
+				if result.Index != i {
+					t.Errorf("result %d has index %d", i, result.Index)
+				}
+				statuses = append(statuses, result.Status)
+			}
+			if fmt.Sprint(statuses) != fmt.Sprint(tt.wantStatuses) {
+				t.Errorf("statuses = %v, want %v", statuses, tt.wantStatuses)
+			}
+
+			st := orgs.Get(store.DefaultOrg)
+			if _, err := st.GetPullRequest("pr-2"); (err == nil) != tt.wantPR2 {
+				t.Errorf("pr-2 exists = %v, want %v", err == nil, tt.wantPR2)
+			}
+			pr, _ := st.GetPullRequest("pr-1")
+			if merged := pr.Status == store.StatusMerged; merged != tt.wantMerged {
+				t.Errorf("pr-1 merged = %v, want %v", merged, tt.wantMerged)
+			}
+			team, _ := st.GetTeam("backend")
+			if team.LeadID != tt.wantLead {
+				t.Errorf("lead = %q, want %q", team.LeadID, tt.wantLead)
+			}
+			if got := len(log.Query(audit.Filter{Org: store.DefaultOrg})) - audited; got != tt.wantAudit {
+				t.Errorf("audit entries = %d, want %d", got, tt.wantAudit)
+			}
+		})
+	}
+}
+
+func TestBatchable(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/team/add", true},
+		{"/pullRequest/get?pull_request_id=pr-1", true},
+		{"/v1/teams", true},
+		{"/v1/teams/backend/lead", true},
+		{"/v1/pull-requests/pr-1/merge", true},
+		{"/v1/teamsx", false},
+		{"/v1/pull-requestsx/pr-1", false},
+		{"/batch", false},
+		{"/v1/batch", false},
+		{"/audit", false},
+		{"/webhooks/add", false},
+		{"/metrics", false},
+	}
+	for _, tt := range tests {
+		if got := batchable(tt.path); got != tt.want {
+			t.Errorf("batchable(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package httpserver
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+// setETag exposes the version of the pull request or team in the response.
+func setETag(w http.ResponseWriter, version uint64) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatUint(version, 10)))
+}
+
+// ifMatch returns the version a mutating request is conditioned on, or
+// store.AnyVersion when it sends no If-Match or "*". Weak tags never match a
+// strong comparison, so they fail with 412 like a stale version. It reports
+// false after writing an error.
+func ifMatch(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return store.AnyVersion, true
+	}
+	if strings.Contains(value, ",") {
+		badRequest(w, "If-Match must be a single entity tag")
+		return 0, false
+	}
+	if strings.HasPrefix(value, "W/") {
+		preconditionFailed(w)
+		return 0, false
+	}
+
+	tag, err := strconv.Unquote(value)
+	if err != nil || !strings.HasPrefix(value, `"`) {
+		badRequest(w, "If-Match must be a quoted entity tag")
+		return 0, false
+	}
+	version, err := strconv.ParseUint(tag, 10, 64)
+	if err != nil || version == store.AnyVersion {
+		preconditionFailed(w)
+		return 0, false
+	}
+	return version, true
+}
+
+func preconditionFailed(w http.ResponseWriter) {
+	writeError(w, http.StatusPreconditionFailed, "PRECONDITION_FAILED", "resource was modified; fetch it again and retry")
+}
@@ -0,0 +1,142 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		header      string
+		wantVersion uint64
+		wantStatus  int
+	}{
+		{"", store.AnyVersion, 0},
+		{"*", store.AnyVersion, 0},
+		{`"3"`, 3, 0},
+		{` "3" `, 3, 0},
+		{`W/"3"`, 0, http.StatusPreconditionFailed},
+		{`"0"`, 0, http.StatusPreconditionFailed},
+		{`"abc"`, 0, http.StatusPreconditionFailed},
+		{`3`, 0, http.StatusBadRequest},
+		{`"3", "4"`, 0, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.Header.Set("If-Match", tt.header)
+			w := httptest.NewRecorder()
+			version, ok := ifMatch(w, r)
+			if ok != (tt.wantStatus == 0) {
+				t.Fatalf("ok = %v, status %d", ok, w.Code)
+			}
+			if !ok && w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if ok && version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+// TestConditionalUpdates sends every conditional operation once with a stale
+// ETag, which must fail with 412 and change nothing, and once with the
+// current one.
+func TestConditionalUpdates(t *testing.T) {
+	type fixture struct {
+		reviewer, idle string
+	}
+	const (
+		onTeam = "/team/get?team_name=backend"
+		onPR   = "/pullRequest/get?pull_request_id=pr-1"
+	)
+	tests := []struct {
+		name     string
+		path     string
+		body     func(f fixture) string
+		resource string
+	}{
+		{"merge", "/pullRequest/merge", func(fixture) string { return `{"pull_request_id":"pr-1"}` }, onPR},
+		{"reassign", "/pullRequest/reassign", func(f fixture) string {
+			return `{"pull_request_id":"pr-1","old_user_id":"` + f.reviewer + `"}`
+		}, onPR},
+		{"addReviewer", "/pullRequest/addReviewer", func(f fixture) string {
+			return `{"pull_request_id":"pr-1","user_id":"` + f.idle + `"}`
+		}, onPR},
+		{"removeReviewer", "/pullRequest/removeReviewer", func(f fixture) string {
+			return `{"pull_request_id":"pr-1","user_id":"` + f.reviewer + `"}`
+		}, onPR},
+		{"review", "/pullRequest/review", func(f fixture) string {
+			return `{"pull_request_id":"pr-1","user_id":"` + f.reviewer + `"}`
+		}, onPR},
+		{"setLead", "/team/setLead", func(fixture) string { return `{"team_name":"backend","user_id":"u2"}` }, onTeam},
+		{"setReviewSLA", "/team/setReviewSLA", func(fixture) string { return `{"team_name":"backend","sla_hours":8}` }, onTeam},
+		{"setIsActive", "/users/setIsActive", func(f fixture) string {
+			return `{"user_id":"` + f.idle + `","is_active":false}`
+		}, onTeam},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestServer(t)
+			mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+			pr := createPR(t, s, "pr-1")
+			f := fixture{reviewer: pr.PR.AssignedReviewers[0]}
+			for _, id := range []string{"u2", "u3", "u4"} {
+				if reviewerIndexOf(pr.PR.AssignedReviewers, id) == -1 {
+					f.idle = id
+				}
+			}
+
+			etag := mustDo(t, s, http.StatusOK, http.MethodGet, tt.resource, "").Header().Get("ETag")
+			version, err := strconv.ParseUint(etag[1:len(etag)-1], 10, 64)
+			if err != nil {
+				t.Fatalf("ETag %q: %v", etag, err)
+			}
+			stale := strconv.Quote(strconv.FormatUint(version+1, 10))
+
+			w := do(t, s, http.MethodPost, tt.path, tt.body(f), "If-Match", stale)
+			if w.Code != http.StatusPreconditionFailed || errorCode(t, w) != "PRECONDITION_FAILED" {
+				t.Fatalf("stale If-Match: %d %s, want 412", w.Code, w.Body.String())
+			}
+			if got := mustDo(t, s, http.StatusOK, http.MethodGet, tt.resource, "").Header().Get("ETag"); got != etag {
+				t.Fatalf("rejected request changed ETag from %s to %s", etag, got)
+			}
+
+			w = mustDo(t, s, http.StatusOK, http.MethodPost, tt.path, tt.body(f), "If-Match", etag)
+			if got := w.Header().Get("ETag"); got != stale {
+				t.Errorf("ETag of the response = %s, want %s", got, stale)
+			}
+			if got := mustDo(t, s, http.StatusOK, http.MethodGet, tt.resource, "").Header().Get("ETag"); got != stale {
+				t.Errorf("ETag after update = %s, want %s", got, stale)
+			}
+		})
+	}
+}
+
+func TestUpdateUserReturnsTeamETag(t *testing.T) {
+	s, _ := newTestServer(t)
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+	etag := mustDo(t, s, http.StatusOK, http.MethodGet, "/v1/teams/backend", "").Header().Get("ETag")
+
+	w := mustDo(t, s, http.StatusOK, http.MethodPatch, "/v1/users/u2", `{"is_active":false}`, "If-Match", etag)
+	next := w.Header().Get("ETag")
+	if got := mustDo(t, s, http.StatusOK, http.MethodGet, "/v1/teams/backend", "").Header().Get("ETag"); next != got || next == etag {
+		t.Fatalf("ETag of the response = %s, want the team's new ETag %s", next, got)
+	}
+
+	// A second change can be chained on the returned tag.
+	w = mustDo(t, s, http.StatusOK, http.MethodPatch, "/v1/users/u2", `{"is_active":true}`, "If-Match", next)
+	if w.Header().Get("ETag") == next {
+		t.Errorf("ETag %s did not change after reactivating u2", next)
+	}
+	// Setting the current value changes nothing and returns the same tag.
+	again := mustDo(t, s, http.StatusOK, http.MethodPatch, "/v1/users/u2", `{"is_active":true}`).Header().Get("ETag")
+	if again != w.Header().Get("ETag") {
+		t.Errorf("ETag after a no-op = %s, want %s", again, w.Header().Get("ETag"))
+	}
+}
@@ -0,0 +1,183 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ToxicSozo/GoDraw/internal/store"
+	"github.com/ToxicSozo/GoDraw/internal/stream"
+	"github.com/ToxicSozo/GoDraw/internal/webhook"
+)
+
+const streamHeartbeat = 15 * time.Second
+
+type streamEventPayload struct {
+	ID            uint64              `json:"id"`
+	Type          string              `json:"type"`
+	At            string              `json:"at"`
+	TeamName      string              `json:"team_name,omitempty"`
+	PullRequest   pullRequestResponse `json:"pull_request"`
+	Reviewers     []string            `json:"reviewers,omitempty"`
+	ReviewerID    string              `json:"reviewer_id,omitempty"`
+	OldReviewerID string              `json:"old_reviewer_id,omitempty"`
+	NewReviewerID string              `json:"new_reviewer_id,omitempty"`
+	Automatic     bool                `json:"automatic,omitempty"`
+}
+
+func (s *Server) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	var lastID uint64
+	if raw := r.Header.Get("Last-Event-ID"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			badRequest(w, "Last-Event-ID must be a number")
+			return
+		}
+		lastID = id
+	}
+
+	filter := stream.Filter{
+		Org:      requestOrg(r),
+		UserID:   r.URL.Query().Get("user_id"),
+		TeamName: r.URL.Query().Get("team_name"),
+	}
+
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "streaming unsupported")
+		return
+	}
+
+	backlog, events, cancel := s.events.Subscribe(filter, lastID)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, e := range backlog {
+		if writeStreamEvent(w, e) != nil {
+			return
+		}
+	}
+	if rc.Flush() != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			if writeStreamEvent(w, e) != nil {
+				return
+			}
+		}
+		if rc.Flush() != nil {
+			return
+		}
+	}
+}
+
+func writeStreamEvent(w http.ResponseWriter, e stream.Event) error {
+	payload, ok := e.Data.(streamEventPayload)
+	if !ok {
+		return nil
+	}
+	payload.ID = e.ID
+	payload.At = e.At.Format(time.RFC3339)
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+// handleStoreEvent fans store events out to the configured integrations.
+func (s *Server) handleStoreEvent(e store.Event) {
+	s.auditStoreEvent(e)
+
+	switch e := e.(type) {
+	case store.PRCreated:
+		if len(e.PR.AssignedReviewers) > 0 {
+			s.publishAssigned(e.Org, e.PR, e.TeamName, e.PR.AssignedReviewers, e.At)
+		}
+	case store.ReviewerAdded:
+		s.publishAssigned(e.Org, e.PR, e.TeamName, []string{e.ReviewerID}, e.At)
+	case store.ReviewerReassigned:
+		if s.webhooks != nil {
+			s.webhooks.Publish(e.Org, webhook.EventReviewerReassigned, webhook.ReassignedData{
+				PullRequest:   webhook.NewPullRequestData(e.PR),
+				OldReviewerID: e.OldReviewerID,
+				NewReviewerID: e.NewReviewerID,
+				Automatic:     e.Automatic,
+			})
+		}
+		s.publishStream(e.Org, stream.EventReassignment, e.PR, e.TeamName, e.At, []string{e.OldReviewerID, e.NewReviewerID}, streamEventPayload{
+			OldReviewerID: e.OldReviewerID,
+			NewReviewerID: e.NewReviewerID,
+			Automatic:     e.Automatic,
+		})
+	case store.ReviewSubmitted:
+		s.publishStream(e.Org, stream.EventReview, e.PR, e.TeamName, e.At, []string{e.ReviewerID}, streamEventPayload{
+			ReviewerID: e.ReviewerID,
+		})
+	case store.PRMerged:
+		if s.webhooks != nil {
+			s.webhooks.Publish(e.Org, webhook.EventPullRequestMerged, webhook.MergedData{PullRequest: webhook.NewPullRequestData(e.PR)})
+		}
+		s.publishStream(e.Org, stream.EventMerge, e.PR, e.TeamName, e.At, e.PR.AssignedReviewers, streamEventPayload{})
+	}
+}
+
+func (s *Server) publishAssigned(org string, pr *store.PullRequest, teamName string, reviewers []string, at time.Time) {
+	if s.webhooks != nil {
+		s.webhooks.Publish(org, webhook.EventReviewerAssigned, webhook.AssignedData{
+			PullRequest: webhook.NewPullRequestData(pr),
+			Reviewers:   append([]string{}, reviewers...),
+		})
+	}
+	s.publishStream(org, stream.EventAssignment, pr, teamName, at, reviewers, streamEventPayload{
+		Reviewers: append([]string{}, reviewers...),
+	})
+}
+
+func (s *Server) publishStream(org, eventType string, pr *store.PullRequest, teamName string, at time.Time, users []string, payload streamEventPayload) {
+	if s.events == nil {
+		return
+	}
+
+	payload.Type = eventType
+	payload.TeamName = teamName
+	payload.PullRequest = makePullRequestResponse(pr)
+
+	s.events.Publish(stream.Event{
+		Type:          eventType,
+		Org:           org,
+		PullRequestID: pr.ID,
+		TeamName:      teamName,
+		Users:         append([]string{pr.AuthorID}, users...),
+		At:            at,
+		Data:          payload,
+	})
+}
@@ -0,0 +1,186 @@
+package httpserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ToxicSozo/GoDraw/internal/stream"
+)
+
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+type sseClient struct {
+	resp   *http.Response
+	lines  *bufio.Scanner
+	cancel context.CancelFunc
+}
+
+// streamServer serves s over HTTP. It is closed after the streams opened on it,
+// which only end when their client disconnects.
+func streamServer(t *testing.T, s *Server) *httptest.Server {
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// openStream connects to the event stream of srv. headers alternate names and
+// values.
+func openStream(t *testing.T, srv *httptest.Server, path string, headers ...string) *sseClient {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+path, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		cancel()
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	c := &sseClient{resp: resp, lines: bufio.NewScanner(resp.Body), cancel: cancel}
+	t.Cleanup(c.close)
+	return c
+}
+
+func (c *sseClient) close() {
+	c.cancel()
+	c.resp.Body.Close()
+}
+
+// next reads one event, skipping comments, and fails after a second.
+func (c *sseClient) next(t *testing.T) sseEvent {
+	t.Helper()
+	timer := time.AfterFunc(time.Second, c.close)
+	defer timer.Stop()
+
+	var e sseEvent
+	for c.lines.Scan() {
+		line := c.lines.Text()
+		switch {
+		case line == "" && e.id != "":
+			return e
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	t.Fatalf("stream ended before an event: %v", c.lines.Err())
+	return e
+}
+
+func TestEventStreamFraming(t *testing.T) {
+	s, _ := newTestServer(t, WithEventStream(stream.New(stream.Config{})))
+	srv := streamServer(t, s)
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+
+	c := openStream(t, srv, "/events/stream")
+	if got := c.resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", got)
+	}
+	pr := createPR(t, s, "pr-1")
+
+	e := c.next(t)
+	if e.id != "1" || e.event != stream.EventAssignment {
+		t.Fatalf("event = id %q type %q, want id 1 type %s", e.id, e.event, stream.EventAssignment)
+	}
+	var payload struct {
+		ID          uint64   `json:"id"`
+		Type        string   `json:"type"`
+		TeamName    string   `json:"team_name"`
+		Reviewers   []string `json:"reviewers"`
+		PullRequest struct {
+			ID string `json:"pull_request_id"`
+		} `json:"pull_request"`
+	}
+	if err := json.Unmarshal([]byte(e.data), &payload); err != nil {
+		t.Fatalf("data %q: %v", e.data, err)
+	}
+	if payload.ID != 1 || payload.Type != stream.EventAssignment || payload.TeamName != "backend" ||
+		payload.PullRequest.ID != "pr-1" || strings.Join(payload.Reviewers, ",") != strings.Join(pr.PR.AssignedReviewers, ",") {
+		t.Errorf("data = %s, want assignment of %v to pr-1 in backend", e.data, pr.PR.AssignedReviewers)
+	}
+}
+
+func TestEventStreamIsScopedToOrg(t *testing.T) {
+	s, _ := newTestServer(t, WithEventStream(stream.New(stream.Config{})))
+	srv := streamServer(t, s)
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend, orgHeader, "globex")
+
+	c := openStream(t, srv, "/events/stream", orgHeader, "globex")
+	createPR(t, s, "default-pr")
+	createPR(t, s, "globex-pr", orgHeader, "globex")
+
+	if e := c.next(t); !strings.Contains(e.data, `"pull_request_id":"globex-pr"`) {
+		t.Errorf("first globex event = %s, want the globex pull request", e.data)
+	}
+}
+
+func TestEventStreamResumesAfterLastEventID(t *testing.T) {
+	s, _ := newTestServer(t, WithEventStream(stream.New(stream.Config{})))
+	srv := streamServer(t, s)
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+
+	// Events reach the broker asynchronously; a live subscriber sees them
+	// all before the reconnecting one asks for the backlog.
+	live := openStream(t, srv, "/events/stream")
+	for _, id := range []string{"pr-1", "pr-2", "pr-3"} {
+		createPR(t, s, id)
+	}
+	for range 3 {
+		live.next(t)
+	}
+
+	c := openStream(t, srv, "/events/stream", "Last-Event-ID", "1")
+	for _, want := range []string{"2", "3"} {
+		if e := c.next(t); e.id != want {
+			t.Fatalf("resumed event id = %s, want %s", e.id, want)
+		}
+	}
+	createPR(t, s, "pr-4")
+	if e := c.next(t); e.id != "4" {
+		t.Errorf("live event after the backlog has id %s, want 4", e.id)
+	}
+
+	w := do(t, s, http.MethodGet, "/events/stream", "", "Last-Event-ID", "abc")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid Last-Event-ID = %d, want 400", w.Code)
+	}
+}
+
+func TestEventStreamEndsWhenBrokerCloses(t *testing.T) {
+	events := stream.New(stream.Config{})
+	s, _ := newTestServer(t, WithEventStream(events))
+	srv := streamServer(t, s)
+
+	c := openStream(t, srv, "/events/stream")
+	events.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for c.lines.Scan() {
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("stream is still open after the broker closed")
+	}
+}
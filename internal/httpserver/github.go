@@ -0,0 +1,142 @@
+package httpserver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubPullRequestEvent struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Number int        `json:"number"`
+		Title  string     `json:"title"`
+		Draft  bool       `json:"draft"`
+		Merged bool       `json:"merged"`
+		User   githubUser `json:"user"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Sender githubUser `json:"sender"`
+}
+
+func (s *Server) handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		badRequest(w, "cannot read payload")
+		return
+	}
+
+	// Each organization has its own secret, so a delivery signed for one
+	// organization is rejected when ?org= names another.
+	secret, ok := s.githubSecrets[requestOrg(r)]
+	if !ok || !validGitHubSignature(secret, body, r.Header.Get("X-Hub-Signature-256")) {
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid signature")
+		return
+	}
+
+	switch r.Header.Get("X-GitHub-Event") {
+	case "ping":
+		writeJSON(w, http.StatusOK, ingestResponse{Action: "ping", Result: ingestIgnored})
+		return
+	case "pull_request":
+	default:
+		writeJSON(w, http.StatusAccepted, ingestResponse{Result: ingestIgnored})
+		return
+	}
+
+	var event githubPullRequestEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		badRequest(w, "invalid JSON payload")
+		return
+	}
+
+	prID := event.pullRequestID()
+	if prID == "" {
+		badRequest(w, "repository.full_name and pull_request.number are required")
+		return
+	}
+
+	resp := ingestResponse{Action: event.Action, PullRequestID: prID, Result: ingestIgnored}
+
+	login := event.PullRequest.User.Login
+	if login == "" {
+		login = event.Sender.Login
+	}
+
+	st := s.tenant(r)
+	switch event.Action {
+	case "opened", "ready_for_review":
+		if event.PullRequest.Draft {
+			break
+		}
+		if resp.Result, ok = s.ingestOpen(w, st, prID, event.PullRequest.Title, login); !ok {
+			return
+		}
+	case "reopened":
+		if resp.Result, ok = s.ingestReopen(w, st, prID, event.PullRequest.Title, login); !ok {
+			return
+		}
+	case "closed":
+		if event.PullRequest.Merged {
+			resp.Result, ok = s.ingestMerge(w, st, prID)
+		} else {
+			resp.Result, ok = s.ingestClose(w, st, prID)
+		}
+		if !ok {
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func githubPullRequestID(body []byte) string {
+	var event githubPullRequestEvent
+	if json.Unmarshal(body, &event) != nil {
+		return ""
+	}
+	return event.pullRequestID()
+}
+
+// pullRequestID maps the event to the service PR ID "<owner>/<repo>#<number>".
+func (e githubPullRequestEvent) pullRequestID() string {
+	number := e.PullRequest.Number
+	if number == 0 {
+		number = e.Number
+	}
+	if e.Repository.FullName == "" || number == 0 {
+		return ""
+	}
+	return e.Repository.FullName + "#" + strconv.Itoa(number)
+}
+
+func validGitHubSignature(secret string, body []byte, header string) bool {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,181 @@
+package httpserver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+const githubSecret = "github-secret"
+
+func githubSignature(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestGitHubSecretBelongsToOrg(t *testing.T) {
+	s, _ := newTestServer(t, WithGitHubSecret("acme", "acme-secret"), WithGitHubSecret("globex", "globex-secret"))
+
+	const body = `{"zen":"hi"}`
+	tests := []struct {
+		name       string
+		path       string
+		secret     string
+		wantStatus int
+	}{
+		{"own organization", "/integrations/github?org=acme", "acme-secret", http.StatusOK},
+		{"secret of another organization", "/integrations/github?org=globex", "acme-secret", http.StatusUnauthorized},
+		{"organization without a secret", "/integrations/github?org=initech", "acme-secret", http.StatusUnauthorized},
+		{"default organization without a secret", "/integrations/github", "acme-secret", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(t, s, http.MethodPost, tt.path, body,
+				"X-GitHub-Event", "ping", "X-Hub-Signature-256", githubSignature(tt.secret, body))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d %s, want %d", w.Code, w.Body.String(), tt.wantStatus)
+			}
+		})
+	}
+}
+
+// githubPullRequest renders a pull_request event for acme/api#42.
+func githubPullRequest(action, login string, draft, merged bool) string {
+	return fmt.Sprintf(`{"action":%q,"number":42,"pull_request":{"number":42,"title":"Add caching","draft":%t,"merged":%t,"user":{"login":%q}},"repository":{"full_name":"acme/api"},"sender":{"login":"bob"}}`,
+		action, draft, merged, login)
+}
+
+// sendGitHub delivers a signed event to s.
+func sendGitHub(t *testing.T, s *Server, event, body string) (int, ingestResponse) {
+	t.Helper()
+	w := do(t, s, http.MethodPost, "/integrations/github", body,
+		"X-GitHub-Event", event, "X-Hub-Signature-256", githubSignature(githubSecret, body))
+	var resp ingestResponse
+	if w.Code < 300 {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode %q: %v", w.Body.String(), err)
+		}
+	}
+	return w.Code, resp
+}
+
+func TestGitHubSignature(t *testing.T) {
+	s, _ := newTestServer(t, WithGitHubSecret(store.DefaultOrg, githubSecret))
+
+	const body = `{"zen":"hi"}`
+	tests := []struct {
+		name       string
+		signature  string
+		wantStatus int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"not sha256", "sha1=" + githubSignature(githubSecret, body)[len("sha256="):], http.StatusUnauthorized},
+		{"not hex", "sha256=zz", http.StatusUnauthorized},
+		{"wrong secret", githubSignature("other", body), http.StatusUnauthorized},
+		{"other body", githubSignature(githubSecret, body+" "), http.StatusUnauthorized},
+		{"valid", githubSignature(githubSecret, body), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := []string{"X-GitHub-Event", "ping"}
+			if tt.signature != "" {
+				headers = append(headers, "X-Hub-Signature-256", tt.signature)
+			}
+			w := do(t, s, http.MethodPost, "/integrations/github", body, headers...)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d %s, want %d", w.Code, w.Body.String(), tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestGitHubEvents(t *testing.T) {
+	s, _ := newTestServer(t, WithGitHubSecret(store.DefaultOrg, githubSecret))
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+
+	tests := []struct {
+		name       string
+		event      string
+		body       string
+		wantStatus int
+		wantAction string
+		wantResult string
+	}{
+		{"ping", "ping", `{"zen":"hi"}`, http.StatusOK, "ping", ingestIgnored},
+		{"unknown event", "push", `{"ref":"main"}`, http.StatusAccepted, "", ingestIgnored},
+		{"missing event header", "", `{}`, http.StatusAccepted, "", ingestIgnored},
+		{"invalid JSON", "pull_request", `{`, http.StatusBadRequest, "", ""},
+		{"missing repository", "pull_request", `{"action":"opened","number":1}`, http.StatusBadRequest, "", ""},
+		{"unknown author", "pull_request", githubPullRequest("opened", "mallory", false, false), http.StatusNotFound, "", ""},
+		{"unhandled action", "pull_request", githubPullRequest("labeled", "alice", false, false), http.StatusOK, "labeled", ingestIgnored},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := sendGitHub(t, s, tt.event, tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if resp.Action != tt.wantAction || resp.Result != tt.wantResult {
+				t.Errorf("response = %+v, want action %q result %q", resp, tt.wantAction, tt.wantResult)
+			}
+		})
+	}
+	mustDo(t, s, http.StatusNotFound, http.MethodGet, "/pullRequest/get?pull_request_id="+url.QueryEscape("acme/api#42"), "")
+}
+
+func TestGitHubPullRequestLifecycle(t *testing.T) {
+	s, _ := newTestServer(t, WithGitHubSecret(store.DefaultOrg, githubSecret))
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+
+	steps := []struct {
+		name       string
+		action     string
+		draft      bool
+		merged     bool
+		wantStatus int
+		wantResult string
+		wantPR     string // PR status afterwards; empty if it must not exist
+	}{
+		{"draft is skipped", "opened", true, false, http.StatusOK, ingestIgnored, ""},
+		{"ready for review creates", "ready_for_review", false, false, http.StatusOK, ingestCreated, store.StatusOpen},
+		{"redelivered open", "opened", false, false, http.StatusOK, ingestExists, store.StatusOpen},
+		{"synchronize is ignored", "synchronize", false, false, http.StatusOK, ingestIgnored, store.StatusOpen},
+		{"closed without merge", "closed", false, false, http.StatusOK, ingestClosed, store.StatusClosed},
+		{"reopened", "reopened", false, false, http.StatusOK, ingestReopened, store.StatusOpen},
+		{"reopened while open", "reopened", false, false, http.StatusOK, ingestExists, store.StatusOpen},
+		{"merged", "closed", false, true, http.StatusOK, ingestMerged, store.StatusMerged},
+		{"redelivered merge", "closed", false, true, http.StatusOK, ingestMerged, store.StatusMerged},
+		{"reopen after merge", "reopened", false, false, http.StatusConflict, "", store.StatusMerged},
+	}
+	for _, step := range steps {
+		status, resp := sendGitHub(t, s, "pull_request", githubPullRequest(step.action, "alice", step.draft, step.merged))
+		if status != step.wantStatus {
+			t.Fatalf("%s: status = %d, want %d", step.name, status, step.wantStatus)
+		}
+		if status == http.StatusOK && (resp.Result != step.wantResult || resp.PullRequestID != "acme/api#42") {
+			t.Errorf("%s: response = %+v, want result %s for acme/api#42", step.name, resp, step.wantResult)
+		}
+
+		w := do(t, s, http.MethodGet, "/pullRequest/get?pull_request_id="+url.QueryEscape("acme/api#42"), "")
+		if step.wantPR == "" {
+			if w.Code != http.StatusNotFound {
+				t.Errorf("%s: pull request exists: %s", step.name, w.Body.String())
+			}
+			continue
+		}
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: get = %d %s", step.name, w.Code, w.Body.String())
+		}
+		if pr := decodePR(t, w).PR; pr.Status != step.wantPR || pr.AuthorID != "u1" {
+			t.Errorf("%s: pull request %s by %s, want %s by u1", step.name, pr.Status, pr.AuthorID, step.wantPR)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package httpserver
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type gitlabMergeRequestEvent struct {
+	ObjectKind string `json:"object_kind"`
+	User       struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID    int    `json:"iid"`
+		Title  string `json:"title"`
+		Action string `json:"action"`
+		State  string `json:"state"`
+	} `json:"object_attributes"`
+}
+
+func (s *Server) handleGitLabWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	want, ok := s.gitlabTokens[requestOrg(r)]
+	token := r.Header.Get("X-Gitlab-Token")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(want)) != 1 {
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid token")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		badRequest(w, "cannot read payload")
+		return
+	}
+
+	var event gitlabMergeRequestEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		badRequest(w, "invalid JSON payload")
+		return
+	}
+
+	if event.ObjectKind != "merge_request" {
+		writeJSON(w, http.StatusAccepted, ingestResponse{Result: ingestIgnored})
+		return
+	}
+
+	attrs := event.ObjectAttributes
+	prID := event.pullRequestID()
+	if prID == "" {
+		badRequest(w, "project.path_with_namespace and object_attributes.iid are required")
+		return
+	}
+
+	resp := ingestResponse{Action: attrs.Action, PullRequestID: prID, Result: ingestIgnored}
+
+	st := s.tenant(r)
+	switch attrs.Action {
+	case "open":
+		if resp.Result, ok = s.ingestOpen(w, st, prID, attrs.Title, event.User.Username); !ok {
+			return
+		}
+	case "reopen":
+		if resp.Result, ok = s.ingestReopen(w, st, prID, attrs.Title, event.User.Username); !ok {
+			return
+		}
+	case "merge":
+		if resp.Result, ok = s.ingestMerge(w, st, prID); !ok {
+			return
+		}
+	case "close":
+		if resp.Result, ok = s.ingestClose(w, st, prID); !ok {
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func gitlabPullRequestID(body []byte) string {
+	var event gitlabMergeRequestEvent
+	if json.Unmarshal(body, &event) != nil {
+		return ""
+	}
+	return event.pullRequestID()
+}
+
+// pullRequestID maps the event to the service PR ID "<group>/<project>!<iid>".
+func (e gitlabMergeRequestEvent) pullRequestID() string {
+	if e.Project.PathWithNamespace == "" || e.ObjectAttributes.IID == 0 {
+		return ""
+	}
+	return e.Project.PathWithNamespace + "!" + strconv.Itoa(e.ObjectAttributes.IID)
+}
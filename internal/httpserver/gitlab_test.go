@@ -0,0 +1,151 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+const gitlabToken = "gitlab-token"
+
+// gitlabMergeRequest renders a merge request event for acme/api!7.
+func gitlabMergeRequest(action, username string) string {
+	return fmt.Sprintf(`{"object_kind":"merge_request","user":{"username":%q},"project":{"path_with_namespace":"acme/api"},"object_attributes":{"iid":7,"title":"Add caching","action":%q}}`,
+		username, action)
+}
+
+// sendGitLab delivers an event with the default organization's token to s.
+func sendGitLab(t *testing.T, s *Server, body string) (int, ingestResponse) {
+	t.Helper()
+	w := do(t, s, http.MethodPost, "/integrations/gitlab", body, "X-Gitlab-Token", gitlabToken)
+	var resp ingestResponse
+	if w.Code < 300 {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode %q: %v", w.Body.String(), err)
+		}
+	}
+	return w.Code, resp
+}
+
+func TestGitLabTokenBelongsToOrg(t *testing.T) {
+	s, _ := newTestServer(t, WithGitLabToken("acme", "acme-token"), WithGitLabToken("globex", "globex-token"))
+
+	const body = `{"object_kind":"push"}`
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{"own organization", "/integrations/gitlab?org=acme", "acme-token", http.StatusAccepted},
+		{"token of another organization", "/integrations/gitlab?org=globex", "acme-token", http.StatusUnauthorized},
+		{"organization without a token", "/integrations/gitlab?org=initech", "acme-token", http.StatusUnauthorized},
+		{"default organization without a token", "/integrations/gitlab", "acme-token", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(t, s, http.MethodPost, tt.path, body, "X-Gitlab-Token", tt.token)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d %s, want %d", w.Code, w.Body.String(), tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestGitLabToken(t *testing.T) {
+	s, _ := newTestServer(t, WithGitLabToken(store.DefaultOrg, gitlabToken))
+
+	const body = `{"object_kind":"push"}`
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong", "other-token", http.StatusUnauthorized},
+		{"prefix of the token", gitlabToken[:5], http.StatusUnauthorized},
+		{"valid", gitlabToken, http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers []string
+			if tt.token != "" {
+				headers = []string{"X-Gitlab-Token", tt.token}
+			}
+			w := do(t, s, http.MethodPost, "/integrations/gitlab", body, headers...)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d %s, want %d", w.Code, w.Body.String(), tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestGitLabEvents(t *testing.T) {
+	s, _ := newTestServer(t, WithGitLabToken(store.DefaultOrg, gitlabToken))
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantResult string
+	}{
+		{"other object kind", `{"object_kind":"push"}`, http.StatusAccepted, ingestIgnored},
+		{"invalid JSON", `{`, http.StatusBadRequest, ""},
+		{"missing project", `{"object_kind":"merge_request","object_attributes":{"iid":7,"action":"open"}}`, http.StatusBadRequest, ""},
+		{"unknown author", gitlabMergeRequest("open", "mallory"), http.StatusNotFound, ""},
+		{"update is ignored", gitlabMergeRequest("update", "alice"), http.StatusOK, ingestIgnored},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := sendGitLab(t, s, tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if resp.Result != tt.wantResult {
+				t.Errorf("result = %q, want %q", resp.Result, tt.wantResult)
+			}
+		})
+	}
+	mustDo(t, s, http.StatusNotFound, http.MethodGet, "/pullRequest/get?pull_request_id="+url.QueryEscape("acme/api!7"), "")
+}
+
+func TestGitLabMergeRequestActions(t *testing.T) {
+	s, _ := newTestServer(t, WithGitLabToken(store.DefaultOrg, gitlabToken))
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+
+	steps := []struct {
+		action     string
+		wantStatus int
+		wantResult string
+		wantPR     string
+	}{
+		{"open", http.StatusOK, ingestCreated, store.StatusOpen},
+		{"open", http.StatusOK, ingestExists, store.StatusOpen},
+		{"update", http.StatusOK, ingestIgnored, store.StatusOpen},
+		{"close", http.StatusOK, ingestClosed, store.StatusClosed},
+		{"reopen", http.StatusOK, ingestReopened, store.StatusOpen},
+		{"merge", http.StatusOK, ingestMerged, store.StatusMerged},
+		{"merge", http.StatusOK, ingestMerged, store.StatusMerged},
+		{"close", http.StatusConflict, "", store.StatusMerged},
+		{"reopen", http.StatusConflict, "", store.StatusMerged},
+	}
+	for i, step := range steps {
+		status, resp := sendGitLab(t, s, gitlabMergeRequest(step.action, "alice"))
+		if status != step.wantStatus {
+			t.Fatalf("step %d %s: status = %d, want %d", i, step.action, status, step.wantStatus)
+		}
+		if status == http.StatusOK && (resp.Action != step.action || resp.Result != step.wantResult || resp.PullRequestID != "acme/api!7") {
+			t.Errorf("step %d %s: response = %+v, want result %s for acme/api!7", i, step.action, resp, step.wantResult)
+		}
+
+		w := mustDo(t, s, http.StatusOK, http.MethodGet, "/pullRequest/get?pull_request_id="+url.QueryEscape("acme/api!7"), "")
+		if pr := decodePR(t, w).PR; pr.Status != step.wantPR || pr.AuthorID != "u1" {
+			t.Errorf("step %d %s: pull request %s by %s, want %s by u1", i, step.action, pr.Status, pr.AuthorID, step.wantPR)
+		}
+	}
+}
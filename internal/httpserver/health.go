@@ -0,0 +1,91 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// readyTimeout bounds how long /readyz waits for the store.
+const readyTimeout = 2 * time.Second
+
+// probePaths are polled by the orchestrator. They skip authentication,
+// organization checks and the access log.
+var probePaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/version": true,
+}
+
+// buildTime is set at link time:
+//
+//	go build -ldflags "-X github.com/ToxicSozo/GoDraw/internal/httpserver.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var buildTime string
+
+type statusResponse struct {
+	Status string `json:"status"`
+}
+
+type versionResponse struct {
+	Module     string `json:"module"`
+	Version    string `json:"version"`
+	GoVersion  string `json:"go_version"`
+	Revision   string `json:"revision,omitempty"`
+	CommitTime string `json:"commit_time,omitempty"`
+	Modified   bool   `json:"modified"`
+	BuildTime  string `json:"build_time,omitempty"`
+}
+
+// handleHealthz reports that the process is up and serving HTTP.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, statusResponse{Status: "ok"})
+}
+
+// handleReadyz reports whether the store can serve requests.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+	if err := s.orgs.Ready(ctx); err != nil {
+		writeError(w, http.StatusServiceUnavailable, "NOT_READY", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, statusResponse{Status: "ready"})
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	resp := versionResponse{Version: "unknown", BuildTime: buildTime}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		resp.Module = info.Main.Path
+		resp.Version = info.Main.Version
+		resp.GoVersion = info.GoVersion
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				resp.Revision = setting.Value
+			case "vcs.time":
+				resp.CommitTime = setting.Value
+			case "vcs.modified":
+				resp.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
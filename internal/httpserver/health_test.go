@@ -0,0 +1,74 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ToxicSozo/GoDraw/internal/auth"
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+// TestProbesSkipAuthAndLogs calls each probe on a server that requires a
+// token for everything else and logs every request.
+func TestProbesSkipAuthAndLogs(t *testing.T) {
+	tests := []struct {
+		path       string
+		wantStatus string
+	}{
+		{"/healthz", "ok"},
+		{"/readyz", "ready"},
+		{"/version", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var out bytes.Buffer
+			s, _ := newTestServer(t, WithAuth(auth.NewRegistry()), WithLogger(slog.New(slog.NewJSONHandler(&out, nil))))
+
+			w := mustDo(t, s, http.StatusOK, http.MethodGet, tt.path, "", orgHeader, "unknown")
+			var body map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode %q: %v", w.Body.String(), err)
+			}
+			if tt.wantStatus != "" && body["status"] != tt.wantStatus {
+				t.Errorf("status = %v, want %s", body["status"], tt.wantStatus)
+			}
+			if out.Len() != 0 {
+				t.Errorf("probe was logged: %s", out.String())
+			}
+			if w := do(t, s, http.MethodPost, tt.path, ""); w.Code != http.StatusMethodNotAllowed {
+				t.Errorf("POST = %d, want 405", w.Code)
+			}
+		})
+	}
+}
+
+func TestReadyzFailsWhileStoreIsStuck(t *testing.T) {
+	s, orgs := newTestServer(t)
+	locked, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		_ = orgs.Get(store.DefaultOrg).Transaction(func(*store.Store) error {
+			close(locked)
+			<-release
+			return nil
+		})
+	}()
+	<-locked
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r := httptest.NewRequest(http.MethodGet, "/readyz", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	if w.Code != http.StatusServiceUnavailable || errorCode(t, w) != "NOT_READY" {
+		t.Errorf("stuck store: %d %s, want 503 NOT_READY", w.Code, w.Body.String())
+	}
+
+	close(release)
+	mustDo(t, s, http.StatusOK, http.MethodGet, "/readyz", "")
+}
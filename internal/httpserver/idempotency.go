@@ -0,0 +1,104 @@
+package httpserver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"github.com/ToxicSozo/GoDraw/internal/idempotency"
+)
+
+const (
+	idempotencyHeader = "Idempotency-Key"
+	replayedHeader    = "Idempotent-Replayed"
+	maxIdempotencyKey = 255
+)
+
+// bodyRecorder keeps a copy of the response for later replay.
+type bodyRecorder struct {
+	statusRecorder
+	body bytes.Buffer
+}
+
+func (r *bodyRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.statusRecorder.Write(b)
+}
+
+// WithIdempotency lets clients retry mutating requests safely by sending an
+// Idempotency-Key header; responses are kept in store.
+func WithIdempotency(store *idempotency.Store) Option {
+	return func(s *Server) {
+		s.idempotency = store
+	}
+}
+
+// serveIdempotent runs a mutating request at most once per key. Keys are
+// scoped to the caller's organization and actor; the first response is
+// replayed for retries with the same method, path and body.
+func (s *Server) serveIdempotent(w http.ResponseWriter, r *http.Request, key string) {
+	if len(key) > maxIdempotencyKey {
+		badRequest(w, "Idempotency-Key must be at most 255 characters long")
+		return
+	}
+
+	body, err := bufferBody(w, r)
+	if err != nil {
+		badRequest(w, "cannot read payload")
+		return
+	}
+
+	sum := sha256.New()
+	sum.Write([]byte(r.Method + "\n" + r.URL.EscapedPath() + "\n"))
+	sum.Write(body)
+	fingerprint := hex.EncodeToString(sum.Sum(nil))
+	scoped := requestOrg(r) + "\n" + requestActor(r) + "\n" + claimedActor(r) + "\n" + key
+
+	replay, err := s.idempotency.Begin(scoped, fingerprint)
+	switch {
+	case errors.Is(err, idempotency.ErrKeyReused):
+		writeError(w, http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED", "Idempotency-Key was already used for a different request")
+		return
+	case errors.Is(err, idempotency.ErrInProgress):
+		writeError(w, http.StatusConflict, "IDEMPOTENCY_IN_PROGRESS", "a request with this Idempotency-Key is still in progress")
+		return
+	case replay != nil:
+		// The replay keeps this request's own ID.
+		for name, values := range replay.Header {
+			if name != http.CanonicalHeaderKey(requestIDHeader) {
+				w.Header()[name] = values
+			}
+		}
+		w.Header().Set(replayedHeader, "true")
+		w.WriteHeader(replay.Status)
+		_, _ = w.Write(replay.Body)
+		return
+	}
+
+	// Server errors and panics are not recorded so the client can retry.
+	recorded := false
+	defer func() {
+		if !recorded {
+			s.idempotency.Release(scoped)
+		}
+	}()
+
+	rec := &bodyRecorder{statusRecorder: statusRecorder{ResponseWriter: w}}
+	s.mux.ServeHTTP(rec, r)
+
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status >= http.StatusInternalServerError {
+		return
+	}
+	s.idempotency.Complete(scoped, idempotency.Response{
+		Status: status,
+		Header: rec.Header().Clone(),
+		Body:   rec.body.Bytes(),
+	})
+	recorded = true
+}
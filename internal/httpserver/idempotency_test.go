@@ -0,0 +1,170 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ToxicSozo/GoDraw/internal/audit"
+	"github.com/ToxicSozo/GoDraw/internal/idempotency"
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+const createPR1 = `{"pull_request_id":"pr-1","pull_request_name":"pr-1","author_id":"u1"}`
+
+func TestIdempotency(t *testing.T) {
+	type call struct {
+		path       string
+		body       string
+		headers    []string
+		wantStatus int
+		wantCode   string
+		wantReplay bool
+	}
+	key := []string{idempotencyHeader, "k1"}
+	with := func(extra ...string) []string { return append(append([]string(nil), key...), extra...) }
+
+	tests := []struct {
+		name  string
+		calls []call
+	}{
+		{"retry replays the first response", []call{
+			{path: "/pullRequest/create", body: createPR1, headers: key, wantStatus: http.StatusCreated},
+			{path: "/pullRequest/create", body: createPR1, headers: key, wantStatus: http.StatusCreated, wantReplay: true},
+		}},
+		{"without a key the retry runs", []call{
+			{path: "/pullRequest/create", body: createPR1, headers: key, wantStatus: http.StatusCreated},
+			{path: "/pullRequest/create", body: createPR1, wantStatus: http.StatusConflict, wantCode: "PR_EXISTS"},
+		}},
+		{"client errors are replayed", []call{
+			{path: "/pullRequest/create", body: `{"pull_request_id":"pr-1","pull_request_name":"pr-1","author_id":"u9"}`, headers: key, wantStatus: http.StatusNotFound},
+			{path: "/pullRequest/create", body: `{"pull_request_id":"pr-1","pull_request_name":"pr-1","author_id":"u9"}`, headers: key, wantStatus: http.StatusNotFound, wantCode: "NOT_FOUND", wantReplay: true},
+		}},
+		{"another body is rejected", []call{
+			{path: "/pullRequest/create", body: createPR1, headers: key, wantStatus: http.StatusCreated},
+			{path: "/pullRequest/create", body: strings.Replace(createPR1, "pr-1", "pr-2", 1), headers: key, wantStatus: http.StatusUnprocessableEntity, wantCode: "IDEMPOTENCY_KEY_REUSED"},
+		}},
+		{"another path is rejected", []call{
+			{path: "/pullRequest/create", body: createPR1, headers: key, wantStatus: http.StatusCreated},
+			{path: "/pullRequest/merge", body: `{"pull_request_id":"pr-1"}`, headers: key, wantStatus: http.StatusUnprocessableEntity, wantCode: "IDEMPOTENCY_KEY_REUSED"},
+		}},
+		{"keys are scoped to the claimed actor", []call{
+			{path: "/pullRequest/create", body: createPR1, headers: with(actorHeader, "a"), wantStatus: http.StatusCreated},
+			{path: "/pullRequest/create", body: createPR1, headers: with(actorHeader, "b"), wantStatus: http.StatusConflict, wantCode: "PR_EXISTS"},
+		}},
+		{"keys are scoped to the organization", []call{
+			{path: "/team/add", body: teamBackend, headers: with(orgHeader, "acme"), wantStatus: http.StatusCreated},
+			{path: "/team/add", body: teamBackend, headers: with(orgHeader, "globex"), wantStatus: http.StatusCreated},
+			{path: "/team/add", body: teamBackend, headers: with(orgHeader, "globex"), wantStatus: http.StatusCreated, wantReplay: true},
+		}},
+		{"overlong key", []call{
+			{path: "/pullRequest/create", body: createPR1, headers: []string{idempotencyHeader, strings.Repeat("k", maxIdempotencyKey+1)}, wantStatus: http.StatusBadRequest},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := audit.New(nil)
+			s, _ := newTestServer(t, WithIdempotency(idempotency.New(idempotency.Config{})), WithAudit(log))
+			mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+
+			var first string
+			for i, c := range tt.calls {
+				w := do(t, s, http.MethodPost, c.path, c.body, c.headers...)
+				if w.Code != c.wantStatus {
+					t.Fatalf("call %d: status = %d %s, want %d", i, w.Code, w.Body.String(), c.wantStatus)
+				}
+				if c.wantCode != "" && errorCode(t, w) != c.wantCode {
+					t.Errorf("call %d: code = %s, want %s", i, errorCode(t, w), c.wantCode)
+				}
+				if got := w.Header().Get(replayedHeader) == "true"; got != c.wantReplay {
+					t.Errorf("call %d: replayed = %v, want %v", i, got, c.wantReplay)
+				}
+				if c.wantReplay {
+					if w.Body.String() != first {
+						t.Errorf("call %d: replayed body %s, want %s", i, w.Body.String(), first)
+					}
+				} else {
+					first = w.Body.String()
+				}
+			}
+
+			// Replays are not audited: the team and every executed call are.
+			executed := 1
+			for _, c := range tt.calls {
+				if !c.wantReplay && c.wantStatus != http.StatusBadRequest && c.wantStatus != http.StatusUnprocessableEntity {
+					executed++
+				}
+			}
+			got := 0
+			for _, org := range []string{store.DefaultOrg, "acme", "globex"} {
+				got += len(log.Query(audit.Filter{Org: org}))
+			}
+			if got != executed {
+				t.Errorf("audit entries = %d, want %d", got, executed)
+			}
+		})
+	}
+}
+
+// TestIdempotencyInProgress holds the first request in its handler while the
+// retry arrives.
+func TestIdempotencyInProgress(t *testing.T) {
+	s, _ := newTestServer(t, WithIdempotency(idempotency.New(idempotency.Config{})))
+	entered, release := make(chan struct{}), make(chan struct{})
+	s.mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		r := httptest.NewRequest(http.MethodPost, "/slow", strings.NewReader(`{"n":1}`))
+		r.Header.Set(idempotencyHeader, "k1")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		done <- w
+	}()
+	<-entered
+
+	w := do(t, s, http.MethodPost, "/slow", `{"n":1}`, idempotencyHeader, "k1")
+	if w.Code != http.StatusConflict || errorCode(t, w) != "IDEMPOTENCY_IN_PROGRESS" {
+		t.Errorf("retry in progress: %d %s, want 409 IDEMPOTENCY_IN_PROGRESS", w.Code, w.Body.String())
+	}
+	w = do(t, s, http.MethodPost, "/slow", `{"n":2}`, idempotencyHeader, "k1")
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("other body in progress: %d, want 422", w.Code)
+	}
+
+	close(release)
+	if w := <-done; w.Code != http.StatusAccepted {
+		t.Fatalf("first request = %d, want 202", w.Code)
+	}
+	w = do(t, s, http.MethodPost, "/slow", `{"n":1}`, idempotencyHeader, "k1")
+	if w.Code != http.StatusAccepted || w.Header().Get(replayedHeader) != "true" {
+		t.Errorf("retry after completion: %d replayed=%q, want a 202 replay", w.Code, w.Header().Get(replayedHeader))
+	}
+}
+
+func TestIdempotencySkipsServerErrors(t *testing.T) {
+	s, _ := newTestServer(t, WithIdempotency(idempotency.New(idempotency.Config{})))
+	calls := 0
+	s.mux.HandleFunc("/flaky", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for i, want := range []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusOK} {
+		if w := do(t, s, http.MethodPost, "/flaky", `{}`, idempotencyHeader, "k1"); w.Code != want {
+			t.Fatalf("call %d = %d, want %d", i, w.Code, want)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+}
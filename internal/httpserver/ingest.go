@@ -0,0 +1,107 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+const maxWebhookBody = 1 << 20
+
+const (
+	ingestCreated  = "created"
+	ingestExists   = "exists"
+	ingestMerged   = "merged"
+	ingestClosed   = "closed"
+	ingestReopened = "reopened"
+	ingestIgnored  = "ignored"
+)
+
+type ingestResponse struct {
+	Action        string `json:"action"`
+	PullRequestID string `json:"pull_request_id,omitempty"`
+	Result        string `json:"result"`
+}
+
+// The ingest helpers translate pull request lifecycle events from external
+// forges into store calls. Each one is idempotent so redelivered events do not
+// fail, and writes the error response itself when it returns false.
+
+func (s *Server) ingestOpen(w http.ResponseWriter, st *store.Store, prID, title, username string) (string, bool) {
+	if title == "" {
+		title = prID
+	}
+
+	author, err := st.FindUserByUsername(username)
+	if err != nil {
+		writeIngestError(w, err)
+		return "", false
+	}
+
+	_, err = st.CreatePullRequest(store.CreatePullRequestInput{
+		ID:       prID,
+		Name:     title,
+		AuthorID: author.ID,
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrPullRequestExists) {
+			return ingestExists, true
+		}
+		writeIngestError(w, err)
+		return "", false
+	}
+
+	return ingestCreated, true
+}
+
+func (s *Server) ingestReopen(w http.ResponseWriter, st *store.Store, prID, title, username string) (string, bool) {
+	before, err := st.GetPullRequest(prID)
+	if errors.Is(err, store.ErrPullRequestNotFound) {
+		return s.ingestOpen(w, st, prID, title, username)
+	}
+	if err != nil {
+		writeIngestError(w, err)
+		return "", false
+	}
+	if before.Status == store.StatusOpen {
+		return ingestExists, true
+	}
+
+	if _, err := st.ReopenPullRequest(prID); err != nil {
+		writeIngestError(w, err)
+		return "", false
+	}
+	return ingestReopened, true
+}
+
+func (s *Server) ingestMerge(w http.ResponseWriter, st *store.Store, prID string) (string, bool) {
+	if _, err := st.MergePullRequest(prID); err != nil {
+		writeIngestError(w, err)
+		return "", false
+	}
+	return ingestMerged, true
+}
+
+func (s *Server) ingestClose(w http.ResponseWriter, st *store.Store, prID string) (string, bool) {
+	if _, err := st.ClosePullRequest(prID); err != nil {
+		writeIngestError(w, err)
+		return "", false
+	}
+	return ingestClosed, true
+}
+
+func writeIngestError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrPullRequestNotFound), errors.Is(err, store.ErrUserNotFound), errors.Is(err, store.ErrTeamNotFound):
+		writeNotFound(w)
+	case errors.Is(err, store.ErrUsernameAmbiguous):
+		writeError(w, http.StatusConflict, "USERNAME_AMBIGUOUS", "username maps to more than one user")
+	case errors.Is(err, store.ErrPullRequestMerged):
+		writeError(w, http.StatusConflict, "PR_MERGED", "pull request is already merged")
+	case errors.Is(err, store.ErrPullRequestClosed):
+		writeError(w, http.StatusConflict, "PR_CLOSED", "pull request is closed")
+	default:
+		writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+	}
+}
@@ -0,0 +1,106 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// maxLoggedError caps how much of an error response is kept to find its code.
+const maxLoggedError = 4096
+
+// validRequestID accepts IDs from proxies and clients; anything else is
+// replaced so it cannot forge log lines.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:/+=-]{1,128}$`)
+
+type requestIDContextKey struct{}
+
+// WithLogger writes one structured access log line per request to l.
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = l
+	}
+}
+
+// assignRequestID propagates the caller's X-Request-ID or generates one, and
+// echoes it in the response so writeError can include it in error bodies.
+func assignRequestID(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := r.Header.Get(requestIDHeader)
+	if !validRequestID.MatchString(id) {
+		id = newRequestID()
+	}
+	w.Header().Set(requestIDHeader, id)
+	return r.WithContext(context.WithValue(r.Context(), requestIDContextKey{}, id))
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// logRecorder keeps the start of error responses to report their code.
+type logRecorder struct {
+	statusRecorder
+	errBody bytes.Buffer
+}
+
+func (r *logRecorder) Write(b []byte) (int, error) {
+	if r.status >= http.StatusBadRequest && r.errBody.Len() < maxLoggedError {
+		r.errBody.Write(b[:min(len(b), maxLoggedError-r.errBody.Len())])
+	}
+	return r.statusRecorder.Write(b)
+}
+
+func (r *logRecorder) errorCode() string {
+	var body errorBody
+	if json.Unmarshal(r.errBody.Bytes(), &body) != nil {
+		return ""
+	}
+	return body.Error.Code
+}
+
+// logged writes an access log line after next has served the request.
+func (s *Server) logged(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &logRecorder{statusRecorder: statusRecorder{ResponseWriter: w}}
+		next(rec, r)
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		attrs := []slog.Attr{
+			slog.String("request_id", requestID(r)),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+		}
+		if code := rec.errorCode(); code != "" {
+			attrs = append(attrs, slog.String("error_code", code))
+		}
+
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		s.logger.LogAttrs(r.Context(), level, "request", attrs...)
+	}
+}
@@ -0,0 +1,143 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ToxicSozo/GoDraw/internal/idempotency"
+)
+
+func TestAssignRequestID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"missing", "", false},
+		{"valid", "req-1", true},
+		{"proxy style", "a1b2/c3+d4=:e.5_f", true},
+		{"longest", strings.Repeat("a", 128), true},
+		{"too long", strings.Repeat("a", 129), false},
+		{"spaces", "req 1", false},
+		{"line break", "req\n{\"level\":\"ERROR\"}", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(requestIDHeader, tt.header)
+			w := httptest.NewRecorder()
+			r = assignRequestID(w, r)
+
+			id := requestID(r)
+			if got := w.Header().Get(requestIDHeader); got != id {
+				t.Errorf("header %q, context %q", got, id)
+			}
+			if tt.keep && id != tt.header {
+				t.Errorf("id = %q, want %q", id, tt.header)
+			}
+			if !tt.keep && (id == tt.header || !validRequestID.MatchString(id)) {
+				t.Errorf("id = %q, want a generated one", id)
+			}
+		})
+	}
+}
+
+type logLine struct {
+	Msg       string  `json:"msg"`
+	Level     string  `json:"level"`
+	RequestID string  `json:"request_id"`
+	Method    string  `json:"method"`
+	Path      string  `json:"path"`
+	Status    int     `json:"status"`
+	Latency   float64 `json:"latency_ms"`
+	ErrorCode string  `json:"error_code"`
+}
+
+func TestAccessLog(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		wantLine *logLine
+	}{
+		{"success", http.MethodGet, "/team/get?team_name=backend", "",
+			&logLine{Level: "INFO", Method: http.MethodGet, Path: "/team/get", Status: http.StatusOK}},
+		{"client error keeps its code", http.MethodGet, "/team/get?team_name=missing", "",
+			&logLine{Level: "INFO", Method: http.MethodGet, Path: "/team/get", Status: http.StatusNotFound, ErrorCode: "NOT_FOUND"}},
+		{"conflict", http.MethodPost, "/team/add", teamBackend,
+			&logLine{Level: "INFO", Method: http.MethodPost, Path: "/team/add", Status: http.StatusBadRequest, ErrorCode: "TEAM_EXISTS"}},
+		{"probes are not logged", http.MethodGet, "/healthz", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			s, _ := newTestServer(t, WithLogger(slog.New(slog.NewJSONHandler(&out, nil))))
+			mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+			out.Reset()
+
+			w := do(t, s, tt.method, tt.path, tt.body, requestIDHeader, "req-1")
+			if w.Header().Get(requestIDHeader) != "req-1" {
+				t.Errorf("X-Request-ID = %q, want req-1", w.Header().Get(requestIDHeader))
+			}
+			if tt.wantLine == nil {
+				if out.Len() != 0 {
+					t.Errorf("logged %s", out.String())
+				}
+				return
+			}
+
+			var line logLine
+			if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+				t.Fatalf("log %q: %v", out.String(), err)
+			}
+			want := *tt.wantLine
+			want.Msg, want.RequestID, want.Latency = "request", "req-1", line.Latency
+			if line != want {
+				t.Errorf("log line = %+v, want %+v", line, want)
+			}
+			if w.Code >= http.StatusBadRequest {
+				var body errorBody
+				_ = json.Unmarshal(w.Body.Bytes(), &body)
+				if body.Error.RequestID != "req-1" {
+					t.Errorf("error body request_id = %q, want req-1", body.Error.RequestID)
+				}
+			}
+		})
+	}
+}
+
+func TestServerErrorsLogAtErrorLevel(t *testing.T) {
+	var out bytes.Buffer
+	s, _ := newTestServer(t, WithLogger(slog.New(slog.NewJSONHandler(&out, nil))))
+	s.mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "boom")
+	})
+
+	do(t, s, http.MethodGet, "/broken", "")
+	var line logLine
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("log %q: %v", out.String(), err)
+	}
+	if line.Level != "ERROR" || line.Status != http.StatusInternalServerError || line.ErrorCode != "INTERNAL" {
+		t.Errorf("log line = %+v", line)
+	}
+}
+
+// TestReplayKeepsRequestID checks that a replayed response carries the
+// retry's own X-Request-ID rather than the recorded one.
+func TestReplayKeepsRequestID(t *testing.T) {
+	s, _ := newTestServer(t, WithIdempotency(idempotency.New(idempotency.Config{})))
+	for _, id := range []string{"req-1", "req-2"} {
+		w := mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend,
+			idempotencyHeader, "k1", requestIDHeader, id)
+		if got := w.Header().Get(requestIDHeader); got != id {
+			t.Errorf("X-Request-ID = %q, want %s", got, id)
+		}
+	}
+}
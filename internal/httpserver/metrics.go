@@ -0,0 +1,162 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ToxicSozo/GoDraw/internal/metrics"
+	"github.com/ToxicSozo/GoDraw/internal/reminder"
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+// routeUnmatched labels requests no route matched, so unknown paths do not
+// create new series.
+const routeUnmatched = "unmatched"
+
+// reassignSucceeded is the outcome of a committed reassignment; rejected ones
+// are labelled with their error code.
+const reassignSucceeded = "success"
+
+type serverMetrics struct {
+	registry      *metrics.Registry
+	requests      *metrics.Counter
+	latency       *metrics.Histogram
+	reassignments *metrics.Counter
+}
+
+// WithMetrics records request and domain metrics in reg and serves those of
+// the caller's organization at /metrics.
+func WithMetrics(reg *metrics.Registry) Option {
+	return func(s *Server) {
+		s.metrics = &serverMetrics{registry: reg}
+	}
+}
+
+// registerMetrics creates the server's metrics once the organizations are
+// known.
+func (s *Server) registerMetrics() {
+	reg := s.metrics.registry
+
+	s.metrics.requests = reg.NewCounter("http_requests_total",
+		"HTTP requests by route and status code.", "route", "status")
+	s.metrics.latency = reg.NewHistogram("http_request_duration_seconds",
+		"HTTP request latency by route and status code.", metrics.DefBuckets, "route", "status")
+	s.metrics.reassignments = reg.NewCounter("reviewer_reassignments_total",
+		"Reviewer reassignments by trigger (manual, or automatic by the reminder scheduler) and outcome: success or the error code.", "org", "trigger", "outcome")
+	s.orgs.Subscribe(s.countStoreEvent)
+
+	reg.NewGauge("reviewer_open_pull_requests",
+		"Open pull requests per author team.", func(set func(float64, ...string)) {
+			for _, st := range s.orgs.List() {
+				for team, n := range st.ReviewLoad().OpenByTeam {
+					set(float64(n), st.Org(), team)
+				}
+			}
+		}, "org", "team")
+	reg.NewGauge("reviewer_open_reviews",
+		"Open pull requests each user is assigned to review.", func(set func(float64, ...string)) {
+			for _, st := range s.orgs.List() {
+				for user, n := range st.ReviewLoad().OpenReviewsByUser {
+					set(float64(n), st.Org(), user)
+				}
+			}
+		}, "org", "user")
+	reg.NewGauge("reviewer_pull_requests_without_reviewers",
+		"Open pull requests with no reviewer assigned.", func(set func(float64, ...string)) {
+			for _, st := range s.orgs.List() {
+				set(float64(st.ReviewLoad().WithoutReviewers), st.Org())
+			}
+		}, "org")
+}
+
+// instrumented records the route, status and latency of requests served by
+// next.
+func (s *Server) instrumented(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		_, route := s.mux.Handler(r)
+		if route == "" {
+			route = routeUnmatched
+		}
+
+		rec := &statusRecorder{ResponseWriter: w}
+		next(rec, r)
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		code := strconv.Itoa(status)
+		s.metrics.requests.Inc(route, code)
+		s.metrics.latency.Observe(time.Since(start).Seconds(), route, code)
+	}
+}
+
+// countStoreEvent counts reassignments committed by any caller: handlers,
+// batches, integrations and the reminder scheduler.
+func (s *Server) countStoreEvent(e store.Event) {
+	if ev, ok := e.(store.ReviewerReassigned); ok {
+		trigger := "manual"
+		if ev.Automatic {
+			trigger = "automatic"
+		}
+		s.metrics.reassignments.Inc(ev.Org, trigger, reassignSucceeded)
+	}
+}
+
+// countReassignFailure records why a reassignment request was rejected.
+func (s *Server) countReassignFailure(r *http.Request, err error) {
+	if s.metrics == nil || err == nil {
+		return
+	}
+	s.metrics.reassignments.Inc(requestOrg(r), "manual", reassignErrorCode(err))
+}
+
+// ReminderNotifier counts the automatic reassignments the reminder scheduler
+// failed to make and passes every event on to next.
+func (s *Server) ReminderNotifier(next reminder.Notifier) reminder.Notifier {
+	if s.metrics == nil {
+		return next
+	}
+	return reminder.NotifierFunc(func(e reminder.Event) {
+		if e.Kind == reminder.EventAutoReassignFailed {
+			s.metrics.reassignments.Inc(e.Org, "automatic", reassignErrorCode(e.Err))
+		}
+		next.Notify(e)
+	})
+}
+
+func reassignErrorCode(err error) string {
+	switch {
+	case errors.Is(err, store.ErrNoReplacementCandidate):
+		return "NO_CANDIDATE"
+	case errors.Is(err, store.ErrReviewerNotAssigned):
+		return "NOT_ASSIGNED"
+	case errors.Is(err, store.ErrPreferredReviewerIneligible):
+		return "PREFERRED_INELIGIBLE"
+	case errors.Is(err, store.ErrPullRequestMerged):
+		return "PR_MERGED"
+	case errors.Is(err, store.ErrPullRequestClosed):
+		return "PR_CLOSED"
+	case errors.Is(err, store.ErrVersionMismatch):
+		return "PRECONDITION_FAILED"
+	case errors.Is(err, store.ErrPullRequestNotFound), errors.Is(err, store.ErrUserNotFound), errors.Is(err, store.ErrTeamNotFound):
+		return "NOT_FOUND"
+	}
+	return "INTERNAL"
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	// Domain metrics name teams and users, so callers only see the series of
+	// their own organization.
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = s.metrics.registry.WriteTextWhere(w, "org", requestOrg(r))
+}
@@ -0,0 +1,126 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ToxicSozo/GoDraw/internal/auth"
+	"github.com/ToxicSozo/GoDraw/internal/metrics"
+	"github.com/ToxicSozo/GoDraw/internal/reminder"
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+func TestReassignmentMetrics(t *testing.T) {
+	s, orgs := newTestServer(t, WithMetrics(metrics.NewRegistry()))
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+	pr := createPR(t, s, "pr-1")
+
+	// A manual reassignment over HTTP.
+	mustDo(t, s, http.StatusOK, http.MethodPost, "/pullRequest/reassign",
+		`{"pull_request_id":"pr-1","old_user_id":"`+pr.PR.AssignedReviewers[0]+`"}`)
+
+	// An automatic one, as the reminder scheduler makes it, without HTTP.
+	st, _ := orgs.Lookup(store.DefaultOrg)
+	current, _ := st.GetPullRequest("pr-1")
+	if _, err := st.ReassignReviewer(store.ReassignReviewerInput{
+		PullRequestID: "pr-1",
+		OldReviewerID: current.AssignedReviewers[1],
+		Automatic:     true,
+	}); err != nil {
+		t.Fatalf("ReassignReviewer: %v", err)
+	}
+
+	// A rejected request.
+	w := do(t, s, http.MethodPost, "/pullRequest/reassign", `{"pull_request_id":"pr-1","old_user_id":"u1"}`)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("reassign of the author = %d %s, want 409", w.Code, w.Body.String())
+	}
+
+	// An automatic reassignment the scheduler could not make.
+	var forwarded []string
+	s.ReminderNotifier(reminder.NotifierFunc(func(e reminder.Event) {
+		forwarded = append(forwarded, e.Kind)
+	})).Notify(reminder.Event{
+		Kind:          reminder.EventAutoReassignFailed,
+		Org:           store.DefaultOrg,
+		PullRequestID: "pr-1",
+		Err:           store.ErrNoReplacementCandidate,
+	})
+	if fmt.Sprint(forwarded) != "["+reminder.EventAutoReassignFailed+"]" {
+		t.Fatalf("ReminderNotifier forwarded %v", forwarded)
+	}
+
+	want := []string{
+		`reviewer_reassignments_total{org="default",trigger="automatic",outcome="success"} 1`,
+		`reviewer_reassignments_total{org="default",trigger="automatic",outcome="NO_CANDIDATE"} 1`,
+		`reviewer_reassignments_total{org="default",trigger="manual",outcome="success"} 1`,
+		`reviewer_reassignments_total{org="default",trigger="manual",outcome="NOT_ASSIGNED"} 1`,
+		`http_requests_total{route="/pullRequest/reassign",status="200"} 1`,
+		`http_requests_total{route="/pullRequest/reassign",status="409"} 1`,
+		`reviewer_open_pull_requests{org="default",team="backend"} 1`,
+		`reviewer_pull_requests_without_reviewers{org="default"} 0`,
+	}
+	// Store events reach the counter asynchronously.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		body := mustDo(t, s, http.StatusOK, http.MethodGet, "/metrics", "").Body.String()
+		missing := ""
+		for _, line := range want {
+			if !strings.Contains(body, "\n"+line+"\n") {
+				missing = line
+				break
+			}
+		}
+		if missing == "" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("metrics lack %s:\n%s", missing, body)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMetricsRouteLabels(t *testing.T) {
+	s, _ := newTestServer(t, WithMetrics(metrics.NewRegistry()))
+	do(t, s, http.MethodGet, "/v1/teams/missing", "")
+	do(t, s, http.MethodGet, "/no/such/path", "")
+
+	body := mustDo(t, s, http.StatusOK, http.MethodGet, "/metrics", "").Body.String()
+	for _, line := range []string{
+		`http_requests_total{route="GET /v1/teams/{name}",status="404"} 1`,
+		`http_requests_total{route="unmatched",status="404"} 1`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("metrics lack %s", line)
+		}
+	}
+}
+
+func TestMetricsRequireAuthAndShowOwnOrg(t *testing.T) {
+	tokens := auth.NewRegistry()
+	tokens.Add("acme-admin", auth.Principal{UserID: "admin", Role: auth.RoleAdmin, Org: "acme"})
+	tokens.Add("acme-member", auth.Principal{UserID: "u1", Role: auth.RoleMember, Org: "acme"})
+	tokens.Add("globex-admin", auth.Principal{UserID: "admin", Role: auth.RoleAdmin, Org: "globex"})
+	s, _ := newTestServer(t, WithAuth(tokens), WithMetrics(metrics.NewRegistry()))
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend, "Authorization", "Bearer acme-admin")
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", `{"team_name":"payments","members":[{"user_id":"p1","username":"p1","is_active":true}]}`,
+		"Authorization", "Bearer globex-admin")
+
+	mustDo(t, s, http.StatusUnauthorized, http.MethodGet, "/metrics", "")
+	mustDo(t, s, http.StatusForbidden, http.MethodGet, "/metrics", "", "Authorization", "Bearer acme-member")
+
+	body := mustDo(t, s, http.StatusOK, http.MethodGet, "/metrics", "", "Authorization", "Bearer acme-admin").Body.String()
+	if !strings.Contains(body, `reviewer_open_reviews{org="acme",user="u1"} 0`) {
+		t.Errorf("metrics lack the series of the caller's organization:\n%s", body)
+	}
+	if strings.Contains(body, "globex") || strings.Contains(body, "payments") || strings.Contains(body, "p1") {
+		t.Errorf("metrics show another organization:\n%s", body)
+	}
+	if !strings.Contains(body, `http_requests_total{route="/team/add",status="201"} 2`) {
+		t.Errorf("metrics lack the request counters:\n%s", body)
+	}
+}
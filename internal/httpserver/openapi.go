@@ -0,0 +1,235 @@
+package httpserver
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// openAPIDocument is the service contract. Request bodies are validated
+// against its schemas, so the messages below are part of the contract too.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+var openAPI = mustParseSpec(openAPIDocument)
+
+type apiSpec struct {
+	Paths      map[string]map[string]apiOperation `json:"paths"`
+	Components struct {
+		Schemas map[string]*apiSchema `json:"schemas"`
+	} `json:"components"`
+}
+
+type apiOperation struct {
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema *apiSchema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+}
+
+// apiSchema is the subset of JSON Schema the validator understands.
+type apiSchema struct {
+	Ref              string                `json:"$ref"`
+	Type             string                `json:"type"`
+	Required         []string              `json:"required"`
+	Properties       map[string]*apiSchema `json:"properties"`
+	Items            *apiSchema            `json:"items"`
+	Enum             []string              `json:"enum"`
+	MinLength        int                   `json:"minLength"`
+	ExclusiveMinimum *float64              `json:"exclusiveMinimum"`
+}
+
+func mustParseSpec(data []byte) *apiSpec {
+	var spec apiSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		panic(fmt.Sprintf("httpserver: invalid openapi.json: %v", err))
+	}
+	return &spec
+}
+
+// requestSchema returns the JSON body schema of an operation, or nil when the
+// operation takes no body or is not described. Templated paths such as
+// /v1/users/{id} match any value in place of a parameter.
+func (spec *apiSpec) requestSchema(method, path string) *apiSchema {
+	method = strings.ToLower(method)
+	op, ok := spec.Paths[path][method]
+	if !ok {
+		for template, ops := range spec.Paths {
+			if templateOp, found := ops[method]; found && matchTemplate(template, path) {
+				op, ok = templateOp, true
+				break
+			}
+		}
+	}
+	if !ok || op.RequestBody == nil {
+		return nil
+	}
+	return op.RequestBody.Content["application/json"].Schema
+}
+
+func matchTemplate(template, path string) bool {
+	if !strings.Contains(template, "{") {
+		return false
+	}
+	want := strings.Split(template, "/")
+	got := strings.Split(path, "/")
+	if len(want) != len(got) {
+		return false
+	}
+	for i, segment := range want {
+		if strings.HasPrefix(segment, "{") {
+			if got[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (spec *apiSpec) resolve(schema *apiSchema) *apiSchema {
+	for schema != nil && schema.Ref != "" {
+		schema = spec.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	return schema
+}
+
+// validate checks value against schema and returns the first violation.
+// field is the property path used in the message.
+func (spec *apiSpec) validate(value any, schema *apiSchema, field string) string {
+	schema = spec.resolve(schema)
+	if schema == nil {
+		return ""
+	}
+
+	switch schema.Type {
+	case "object":
+		fields, ok := value.(map[string]any)
+		if !ok {
+			if field == "" {
+				return "request body must be an object"
+			}
+			return field + " must be an object"
+		}
+		for _, name := range schema.Required {
+			if fields[name] == nil {
+				return joinField(field, name) + " is required"
+			}
+		}
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			v, ok := fields[name]
+			if !ok || v == nil {
+				continue
+			}
+			if msg := spec.validate(v, schema.Properties[name], joinField(field, name)); msg != "" {
+				return msg
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return field + " must be an array"
+		}
+		for i, item := range items {
+			if msg := spec.validate(item, schema.Items, fmt.Sprintf("%s[%d]", field, i)); msg != "" {
+				return msg
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return field + " must be a string"
+		}
+		if schema.MinLength == 1 && s == "" {
+			return field + " must not be empty"
+		}
+		if len(s) < schema.MinLength {
+			return fmt.Sprintf("%s must be at least %d characters long", field, schema.MinLength)
+		}
+		if len(schema.Enum) > 0 && !containsString(schema.Enum, s) {
+			return field + " must be one of " + strings.Join(schema.Enum, ", ")
+		}
+	case "number", "integer":
+		n, ok := value.(float64)
+		if !ok || (schema.Type == "integer" && n != float64(int64(n))) {
+			if schema.Type == "integer" {
+				return field + " must be an integer"
+			}
+			return field + " must be a number"
+		}
+		if schema.ExclusiveMinimum != nil && n <= *schema.ExclusiveMinimum {
+			return fmt.Sprintf("%s must be greater than %g", field, *schema.ExclusiveMinimum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return field + " must be a boolean"
+		}
+	}
+	return ""
+}
+
+// validateBody rejects request bodies that do not match the operation's
+// schema in the OpenAPI document. It reports false after writing a 400.
+func (s *Server) validateBody(w http.ResponseWriter, r *http.Request) bool {
+	schema := openAPI.requestSchema(r.Method, r.URL.EscapedPath())
+	if schema == nil {
+		return true
+	}
+
+	body, err := bufferBody(w, r)
+	if err != nil {
+		badRequest(w, "cannot read payload")
+		return false
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		badRequest(w, "invalid JSON payload")
+		return false
+	}
+	if msg := openAPI.validate(value, schema, ""); msg != "" {
+		badRequest(w, msg)
+		return false
+	}
+	return true
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(openAPIDocument)
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ToxicSozo/GoDraw/internal/audit"
+	"github.com/ToxicSozo/GoDraw/internal/auth"
+	"github.com/ToxicSozo/GoDraw/internal/idempotency"
+	"github.com/ToxicSozo/GoDraw/internal/metrics"
+	"github.com/ToxicSozo/GoDraw/internal/store"
+	"github.com/ToxicSozo/GoDraw/internal/stream"
+	"github.com/ToxicSozo/GoDraw/internal/webhook"
+)
+
+const adminToken = "admin-token"
+
+// fullServer enables every optional feature, so every route is registered.
+func fullServer(t *testing.T) *Server {
+	t.Helper()
+	tokens := auth.NewRegistry()
+	tokens.Add(adminToken, auth.Principal{UserID: "admin", Role: auth.RoleAdmin})
+	s, _ := newTestServer(t,
+		WithWebhooks(webhook.New(webhook.Config{})),
+		WithEventStream(stream.New(stream.Config{})),
+		WithAudit(audit.New(nil)),
+		WithAuth(tokens),
+		WithMetrics(metrics.NewRegistry()),
+		WithIdempotency(idempotency.New(idempotency.Config{})),
+		WithGitHubSecret(store.DefaultOrg, "github-secret"),
+		WithGitLabToken(store.DefaultOrg, "gitlab-token"),
+	)
+	return s
+}
+
+// specOperations returns the operations of the embedded document as
+// "method path", and the methods of each path.
+func specOperations(t *testing.T) (map[string]bool, map[string][]string) {
+	t.Helper()
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPIDocument, &doc); err != nil {
+		t.Fatalf("decode openapi.json: %v", err)
+	}
+	ops := make(map[string]bool)
+	methods := make(map[string][]string)
+	for path, item := range doc.Paths {
+		for method := range item {
+			if method == "parameters" {
+				continue
+			}
+			method = strings.ToUpper(method)
+			ops[method+" "+path] = true
+			methods[path] = append(methods[path], method)
+		}
+		sort.Strings(methods[path])
+	}
+	return ops, methods
+}
+
+func TestRoutesMatchSpec(t *testing.T) {
+	s := fullServer(t)
+	ops, methods := specOperations(t)
+
+	registered := make(map[string]bool)
+	for _, route := range s.routes {
+		if registered[route] {
+			t.Errorf("route %s is registered twice", route)
+		}
+		registered[route] = true
+
+		method, path, versioned := strings.Cut(route, " ")
+		if !versioned {
+			// Legacy paths accept any method in the mux; the handler
+			// allows one, which the spec must describe.
+			path = route
+			if got := methods[path]; len(got) != 1 {
+				t.Errorf("legacy route %s has operations %v in the spec, want exactly one", path, got)
+			}
+			continue
+		}
+		if !ops[route] {
+			t.Errorf("route %s %s is missing from the spec", method, path)
+		}
+	}
+
+	for op := range ops {
+		_, path, _ := strings.Cut(op, " ")
+		if !registered[op] && !registered[path] {
+			t.Errorf("spec operation %s has no route", op)
+		}
+	}
+}
+
+// TestLegacyRoutesAllowOnlySpecMethod checks that each legacy handler rejects
+// the methods the spec does not list for it.
+func TestLegacyRoutesAllowOnlySpecMethod(t *testing.T) {
+	s := fullServer(t)
+	_, methods := specOperations(t)
+
+	for _, route := range s.routes {
+		if strings.Contains(route, " ") {
+			continue
+		}
+		allowed := methods[route]
+		for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete} {
+			if containsString(allowed, method) {
+				continue
+			}
+			w := do(t, s, method, route, "", "Authorization", "Bearer "+adminToken)
+			if w.Code != http.StatusMethodNotAllowed {
+				t.Errorf("%s %s = %d, want 405 (spec allows %v)", method, route, w.Code, allowed)
+			}
+		}
+	}
+}
+
+func TestValidateBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		wantMsg string
+	}{
+		{"valid team", http.MethodPost, "/team/add", `{"team_name":"a","members":[{"user_id":"u1","username":"x","is_active":true}]}`, ""},
+		{"not an object", http.MethodPost, "/team/add", `[]`, "request body must be an object"},
+		{"missing required", http.MethodPost, "/team/add", `{"members":[]}`, "team_name is required"},
+		{"null counts as missing", http.MethodPost, "/team/add", `{"team_name":null,"members":[]}`, "team_name is required"},
+		{"wrong type", http.MethodPost, "/team/add", `{"team_name":1,"members":[]}`, "team_name must be a string"},
+		{"array expected", http.MethodPost, "/team/add", `{"team_name":"a","members":{}}`, "members must be an array"},
+		{"nested item", http.MethodPost, "/team/add", `{"team_name":"a","members":[{"user_id":"u1","is_active":"yes"}]}`, "members[0].is_active must be a boolean"},
+		{"empty string", http.MethodPost, "/team/add", `{"team_name":"a","members":[{"user_id":""}]}`, "members[0].user_id must not be empty"},
+		{"number minimum", http.MethodPost, "/team/setReviewSLA", `{"team_name":"a","sla_hours":0}`, "sla_hours must be greater than 0"},
+		{"templated v1 path", http.MethodPatch, "/v1/users/u1", `{"is_active":"no"}`, "is_active must be a boolean"},
+		{"invalid JSON", http.MethodPost, "/team/add", `{`, "invalid JSON payload"},
+		{"operation without body", http.MethodGet, "/team/get", ``, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestServer(t)
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			ok := s.validateBody(w, r)
+			if tt.wantMsg == "" {
+				if !ok {
+					t.Fatalf("rejected: %s", w.Body.String())
+				}
+				return
+			}
+			if ok {
+				t.Fatalf("accepted, want %q", tt.wantMsg)
+			}
+			var body errorBody
+			_ = json.Unmarshal(w.Body.Bytes(), &body)
+			if w.Code != http.StatusBadRequest || body.Error.Message != tt.wantMsg {
+				t.Errorf("got %d %q, want 400 %q", w.Code, body.Error.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestValidateEnumAndInteger(t *testing.T) {
+	zero := 0.0
+	spec := &apiSpec{}
+	tests := []struct {
+		name   string
+		value  any
+		schema *apiSchema
+		want   string
+	}{
+		{"enum ok", "b", &apiSchema{Type: "string", Enum: []string{"a", "b"}}, ""},
+		{"enum bad", "c", &apiSchema{Type: "string", Enum: []string{"a", "b"}}, "f must be one of a, b"},
+		{"min length", "ab", &apiSchema{Type: "string", MinLength: 3}, "f must be at least 3 characters long"},
+		{"integer ok", 3.0, &apiSchema{Type: "integer"}, ""},
+		{"integer fraction", 3.5, &apiSchema{Type: "integer"}, "f must be an integer"},
+		{"number", "3", &apiSchema{Type: "number"}, "f must be a number"},
+		{"exclusive minimum", 0.0, &apiSchema{Type: "number", ExclusiveMinimum: &zero}, "f must be greater than 0"},
+		{"untyped accepts anything", []any{1}, &apiSchema{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := spec.validate(tt.value, tt.schema, "f"); got != tt.want {
+				t.Errorf("validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		path     string
+		want     bool
+	}{
+		{"/v1/users/{id}", "/v1/users/u1", true},
+		{"/v1/users/{id}", "/v1/users/", false},
+		{"/v1/users/{id}", "/v1/users/u1/reviews", false},
+		{"/v1/users/{id}/reviews", "/v1/users/u1/reviews", true},
+		{"/v1/teams", "/v1/teams", false},
+	}
+	for _, tt := range tests {
+		if got := matchTemplate(tt.template, tt.path); got != tt.want {
+			t.Errorf("matchTemplate(%s, %s) = %v, want %v", tt.template, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/ToxicSozo/GoDraw/internal/auth"
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+const (
+	orgHeader = "X-Org-ID"
+	// orgQuery names the organization on public routes, since forges cannot
+	// send custom headers.
+	orgQuery = "org"
+)
+
+var validOrg = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+type (
+	orgContextKey   struct{}
+	storeContextKey struct{}
+)
+
+// resolveOrg determines the organization of a request. An authenticated
+// request belongs to its token's organization and may not name another one;
+// otherwise the X-Org-ID header selects it, or the org query parameter on
+// public routes. Reads of an organization that does not exist get 404; other
+// requests get a store that creates the organization only when a change is
+// committed to it, so requests rejected by validation, authorization or the
+// handler create nothing. release must be called when the request ends. It
+// reports false after writing an error.
+func (s *Server) resolveOrg(w http.ResponseWriter, r *http.Request) (req *http.Request, release func(), ok bool) {
+	org := r.Header.Get(orgHeader)
+
+	if p, ok := auth.FromContext(r.Context()); ok {
+		tokenOrg := orgOf(p)
+		if org != "" && org != tokenOrg {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token does not belong to this organization")
+			return r, nil, false
+		}
+		org = tokenOrg
+	}
+
+	if org == "" && publicPaths[r.URL.Path] {
+		org = r.URL.Query().Get(orgQuery)
+	}
+	if org == "" {
+		org = store.DefaultOrg
+	}
+	if !validOrg.MatchString(org) {
+		badRequest(w, "invalid organization")
+		return r, nil, false
+	}
+
+	if _, exists := s.orgs.Lookup(org); !exists && !publicPaths[r.URL.Path] &&
+		(r.Method == http.MethodGet || r.Method == http.MethodHead) {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "organization not found")
+		return r, nil, false
+	}
+
+	st, release := s.orgs.Acquire(org)
+	ctx := context.WithValue(r.Context(), orgContextKey{}, org)
+	ctx = context.WithValue(ctx, storeContextKey{}, st)
+	return r.WithContext(ctx), release, true
+}
+
+func requestOrg(r *http.Request) string {
+	if org, ok := r.Context().Value(orgContextKey{}).(string); ok {
+		return org
+	}
+	return store.DefaultOrg
+}
+
+// tenant returns the store of the request's organization, or the open
+// transaction of an atomic batch. Handlers must never reach another
+// organization's data.
+func (s *Server) tenant(r *http.Request) *store.Store {
+	if state, ok := batchFromContext(r); ok {
+		return state.tx
+	}
+	if st, ok := r.Context().Value(storeContextKey{}).(*store.Store); ok {
+		return st
+	}
+	st, _ := s.orgs.Lookup(requestOrg(r))
+	return st
+}
@@ -0,0 +1,70 @@
+package httpserver
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestResolveOrgCreatesOnlyOnCommittedWrite(t *testing.T) {
+	s, orgs := newTestServer(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		org        string
+		wantStatus int
+		wantExists bool
+	}{
+		{"default org is readable", http.MethodGet, "/stats/teams", "", "", http.StatusOK, true},
+		{"read of unknown org", http.MethodGet, "/stats/teams", "", "acme", http.StatusNotFound, false},
+		{"v1 read of unknown org", http.MethodGet, "/v1/teams/backend", "", "acme", http.StatusNotFound, false},
+		{"unknown route does not create", http.MethodPost, "/nope", "{}", "acme", http.StatusNotFound, false},
+		{"invalid org", http.MethodPost, "/team/add", teamBackend, "-bad", http.StatusBadRequest, false},
+		{"public route ignores org", http.MethodGet, "/openapi.json", "", "globex", http.StatusOK, false},
+		{"invalid body does not create", http.MethodPost, "/pullRequest/create", "{}", "acme", http.StatusBadRequest, false},
+		{"failed write does not create", http.MethodPost, "/pullRequest/create", `{"pull_request_id":"pr-1","pull_request_name":"x","author_id":"u1"}`, "acme", http.StatusNotFound, false},
+		{"first write creates", http.MethodPost, "/team/add", teamBackend, "acme", http.StatusCreated, true},
+		{"read after write", http.MethodGet, "/team/get?team_name=backend", "", "acme", http.StatusOK, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers []string
+			if tt.org != "" {
+				headers = []string{orgHeader, tt.org}
+			}
+			w := do(t, s, tt.method, tt.path, tt.body, headers...)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d %s, want %d", w.Code, w.Body.String(), tt.wantStatus)
+			}
+			org := tt.org
+			if org == "" {
+				org = "default"
+			}
+			if _, ok := orgs.Lookup(org); ok != tt.wantExists {
+				t.Errorf("org %s exists = %v, want %v", org, ok, tt.wantExists)
+			}
+		})
+	}
+}
+
+func TestOrgsAreIsolated(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend, orgHeader, "acme")
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend, orgHeader, "globex")
+	mustDo(t, s, http.StatusNotFound, http.MethodGet, "/team/get?team_name=backend", "")
+	mustDo(t, s, http.StatusOK, http.MethodGet, "/team/get?team_name=backend", "", orgHeader, "globex")
+}
+
+func TestPublicOrgQueryDoesNotCreate(t *testing.T) {
+	s, orgs := newTestServer(t)
+
+	for _, path := range []string{"/metrics?org=initech", "/integrations/github?org=initech", "/integrations/gitlab?org=initech"} {
+		do(t, s, http.MethodPost, path, "{}")
+		if _, ok := orgs.Lookup("initech"); ok {
+			t.Fatalf("%s created organization initech", path)
+		}
+	}
+}
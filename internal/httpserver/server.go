@@ -3,21 +3,53 @@ package httpserver
 import (
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"github.com/ToxicSozo/GoDraw/internal/audit"
+	"github.com/ToxicSozo/GoDraw/internal/auth"
+	"github.com/ToxicSozo/GoDraw/internal/idempotency"
 	"github.com/ToxicSozo/GoDraw/internal/store"
+	"github.com/ToxicSozo/GoDraw/internal/stream"
+	"github.com/ToxicSozo/GoDraw/internal/webhook"
 )
 
 type Server struct {
-	store *store.Store
-	mux   *http.ServeMux
+	orgs *store.Orgs
+	mux  *http.ServeMux
+	// routes lists every legacy path and /v1 pattern given to handle, so the
+	// OpenAPI document can be checked against them.
+	routes []string
+
+	webhooks *webhook.Dispatcher
+	events   *stream.Broker
+	audit    *audit.Log
+	tokens   *auth.Registry
+
+	idempotency *idempotency.Store
+	metrics     *serverMetrics
+	logger      *slog.Logger
+
+	githubSecrets map[string]string
+	gitlabTokens  map[string]string
+}
+
+type Option func(*Server)
+
+// WithWebhooks enables the webhook management endpoints and publishes
+// assignment and lifecycle events to d.
+func WithWebhooks(d *webhook.Dispatcher) Option {
+	return func(s *Server) {
+		s.webhooks = d
+	}
 }
 
 type errorBody struct {
 	Error struct {
-		Code    string `json:"code"`
-		Message string `json:"message"`
+		Code      string `json:"code"`
+		Message   string `json:"message"`
+		RequestID string `json:"request_id,omitempty"`
 	} `json:"error"`
 }
 
@@ -29,7 +61,9 @@ type teamMemberPayload struct {
 
 type teamPayload struct {
 	TeamName string              `json:"team_name"`
+	LeadID   string              `json:"lead_id,omitempty"`
 	Members  []teamMemberPayload `json:"members"`
+	Version  uint64              `json:"version,omitempty"`
 }
 
 type teamAddRequest teamPayload
@@ -38,6 +72,15 @@ type teamAddResponse struct {
 	Team teamPayload `json:"team"`
 }
 
+type setTeamLeadRequest struct {
+	TeamName string `json:"team_name"`
+	UserID   string `json:"user_id"`
+}
+
+type setTeamLeadResponse struct {
+	Team teamPayload `json:"team"`
+}
+
 type setIsActiveRequest struct {
 	UserID   string `json:"user_id"`
 	IsActive *bool  `json:"is_active"`
@@ -68,12 +111,27 @@ type pullRequestResponse struct {
 	AssignedReviewers []string `json:"assigned_reviewers"`
 	CreatedAt         *string  `json:"createdAt,omitempty"`
 	MergedAt          *string  `json:"mergedAt,omitempty"`
+	ClosedAt          *string  `json:"closedAt,omitempty"`
+	Version           uint64   `json:"version"`
 }
 
 type createPullRequestResponse struct {
 	PR pullRequestResponse `json:"pr"`
 }
 
+type getPullRequestResponse struct {
+	PR pullRequestResponse `json:"pr"`
+}
+
+type previewPullRequestResponse struct {
+	PullRequestID     string                      `json:"pull_request_id"`
+	AuthorID          string                      `json:"author_id"`
+	Strategy          string                      `json:"strategy"`
+	ProposedReviewers []string                    `json:"proposed_reviewers"`
+	Candidates        []string                    `json:"candidates"`
+	Excluded          []candidateExclusionPayload `json:"excluded"`
+}
+
 type mergePullRequestRequest struct {
 	PullRequestID string `json:"pull_request_id"`
 }
@@ -83,8 +141,11 @@ type mergePullRequestResponse struct {
 }
 
 type reassignRequest struct {
-	PullRequestID string `json:"pull_request_id"`
-	OldUserID     string `json:"old_user_id"`
+	PullRequestID    string   `json:"pull_request_id"`
+	OldUserID        string   `json:"old_user_id"`
+	NewUserID        string   `json:"new_user_id"`
+	PreferredUserIDs []string `json:"preferred_user_ids"`
+	FallbackToAuto   bool     `json:"fallback_to_auto"`
 }
 
 type reassignResponse struct {
@@ -92,6 +153,36 @@ type reassignResponse struct {
 	ReplacedBy string              `json:"replaced_by"`
 }
 
+type reviewerChangeRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	UserID        string `json:"user_id"`
+}
+
+type reviewerChangeResponse struct {
+	PR pullRequestResponse `json:"pr"`
+}
+
+type candidateExclusionPayload struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+type assignmentExplanationPayload struct {
+	Kind       string                      `json:"kind"`
+	Strategy   string                      `json:"strategy"`
+	At         string                      `json:"at"`
+	Candidates []string                    `json:"candidates"`
+	Excluded   []candidateExclusionPayload `json:"excluded"`
+	Selected   []string                    `json:"selected"`
+	Replaced   string                      `json:"replaced,omitempty"`
+	TeamName   string                      `json:"team_name,omitempty"`
+}
+
+type assignmentExplainResponse struct {
+	PullRequestID string                         `json:"pull_request_id"`
+	Assignments   []assignmentExplanationPayload `json:"assignments"`
+}
+
 type userReviewsResponse struct {
 	UserID       string             `json:"user_id"`
 	PullRequests []pullRequestShort `json:"pull_requests"`
@@ -104,27 +195,191 @@ type pullRequestShort struct {
 	Status          string `json:"status"`
 }
 
-func New(store *store.Store) *Server {
+// WithEventStream enables the server-sent events endpoint backed by b.
+func WithEventStream(b *stream.Broker) Option {
+	return func(s *Server) {
+		s.events = b
+	}
+}
+
+// WithAudit records every mutating request in l and serves it at /audit.
+func WithAudit(l *audit.Log) Option {
+	return func(s *Server) {
+		s.audit = l
+	}
+}
+
+// WithGitHubSecret enables the GitHub pull_request webhook receiver for org,
+// verifying its deliveries against secret. Repeat it for each organization.
+func WithGitHubSecret(org, secret string) Option {
+	return func(s *Server) {
+		if s.githubSecrets == nil {
+			s.githubSecrets = make(map[string]string)
+		}
+		s.githubSecrets[org] = secret
+	}
+}
+
+// WithGitLabToken enables the GitLab merge request webhook receiver for org,
+// which accepts its deliveries carrying token in X-Gitlab-Token. Repeat it for
+// each organization.
+func WithGitLabToken(org, token string) Option {
+	return func(s *Server) {
+		if s.gitlabTokens == nil {
+			s.gitlabTokens = make(map[string]string)
+		}
+		s.gitlabTokens[org] = token
+	}
+}
+
+func New(orgs *store.Orgs, opts ...Option) *Server {
 	s := &Server{
-		store: store,
-		mux:   http.NewServeMux(),
+		orgs: orgs,
+		mux:  http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.webhooks != nil || s.events != nil || s.audit != nil {
+		s.orgs.Subscribe(s.handleStoreEvent)
+	}
+	if s.metrics != nil {
+		s.registerMetrics()
 	}
 	s.registerRoutes()
 	return s
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r = assignRequestID(w, r)
+
+	probe := probePaths[r.URL.Path]
+	next := s.serve
+	if probe {
+		next = s.mux.ServeHTTP
+	}
+	if s.metrics != nil {
+		next = s.instrumented(next)
+	}
+	if s.logger != nil && !probe {
+		next = s.logged(next)
+	}
+	next(w, r)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	r, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+	r, release, ok := s.resolveOrg(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+	if !s.validateBody(w, r) {
+		return
+	}
+	if key := r.Header.Get(idempotencyHeader); key != "" && s.idempotency != nil && r.Method != http.MethodGet {
+		s.serveIdempotent(w, r, key)
+		return
+	}
 	s.mux.ServeHTTP(w, r)
 }
 
 func (s *Server) registerRoutes() {
-	s.mux.HandleFunc("/team/add", s.handleTeamAdd)
-	s.mux.HandleFunc("/team/get", s.handleTeamGet)
-	s.mux.HandleFunc("/users/setIsActive", s.handleSetIsActive)
-	s.mux.HandleFunc("/pullRequest/create", s.handleCreatePullRequest)
-	s.mux.HandleFunc("/pullRequest/merge", s.handleMergePullRequest)
-	s.mux.HandleFunc("/pullRequest/reassign", s.handleReassign)
-	s.mux.HandleFunc("/users/getReview", s.handleUserReviews)
+	team := auditTarget{entityType: entityTeam, entityID: jsonField("team_name")}
+	user := auditTarget{entityType: entityUser, entityID: jsonField("user_id")}
+	pr := auditTarget{entityType: entityPullRequest, entityID: jsonField("pull_request_id")}
+
+	s.handle("/team/add", "POST /v1/teams",
+		s.audited("team.add", team, s.authorized(adminOnly, s.handleTeamAdd)))
+	s.handle("/team/get", "GET /v1/teams/{name}",
+		s.authorized(anyone, s.handleTeamGet), param("name", "team_name"))
+	s.handle("/team/setLead", "PUT /v1/teams/{name}/lead",
+		s.audited("team.setLead", team, s.authorized(adminOnly, s.handleSetTeamLead)), param("name", "team_name"))
+	s.handle("/team/setReviewSLA", "PUT /v1/teams/{name}/review-sla",
+		s.audited("team.setReviewSLA", team, s.authorized(s.teamLead, s.handleSetReviewSLA)), param("name", "team_name"))
+	s.handle("/users/setIsActive", "PATCH /v1/users/{id}",
+		s.audited("users.setIsActive", user, s.authorized(s.leadOrSelf("user_id"), s.handleSetIsActive)), param("id", "user_id"))
+	s.handle("/users/getReview", "GET /v1/users/{id}/reviews",
+		s.authorized(anyone, s.handleUserReviews), param("id", "user_id"))
+	s.handle("/pullRequest/create", "POST /v1/pull-requests",
+		s.audited("pullRequest.create", pr, s.authorized(s.createPullRequest, s.handleCreatePullRequest)))
+	s.handle("/pullRequest/get", "GET /v1/pull-requests/{id}",
+		s.authorized(anyone, s.handleGetPullRequest), param("id", "pull_request_id"))
+	s.handle("/pullRequest/preview", "POST /v1/pull-requests/preview",
+		s.authorized(anyone, s.handlePreviewPullRequest))
+	s.handle("/pullRequest/overdue", "GET /v1/pull-requests/overdue",
+		s.authorized(anyone, s.handleOverdue))
+	s.handle("/pullRequest/merge", "POST /v1/pull-requests/{id}/merge",
+		s.audited("pullRequest.merge", pr, s.authorized(s.managePullRequest(""), s.handleMergePullRequest)), param("id", "pull_request_id"))
+	s.handle("/pullRequest/reassign", "POST /v1/pull-requests/{id}/reassign",
+		s.audited("pullRequest.reassign", pr, s.authorized(s.managePullRequest("old_user_id"), s.handleReassign)), param("id", "pull_request_id"))
+	s.handle("/pullRequest/addReviewer", "POST /v1/pull-requests/{id}/reviewers",
+		s.audited("pullRequest.addReviewer", pr, s.authorized(s.managePullRequest(""), s.handleAddReviewer)), param("id", "pull_request_id"))
+	s.handle("/pullRequest/removeReviewer", "DELETE /v1/pull-requests/{id}/reviewers/{user_id}",
+		s.audited("pullRequest.removeReviewer", pr, s.authorized(s.managePullRequest("user_id"), s.handleRemoveReviewer)),
+		param("id", "pull_request_id"), param("user_id", "user_id"))
+	s.handle("/pullRequest/assignmentExplain", "GET /v1/pull-requests/{id}/assignments",
+		s.authorized(anyone, s.handleAssignmentExplain), param("id", "pull_request_id"))
+	s.handle("/pullRequest/review", "POST /v1/pull-requests/{id}/reviews",
+		s.audited("pullRequest.review", pr, s.authorized(s.assignedReviewer, s.handleSubmitReview)), param("id", "pull_request_id"))
+	s.handle("/batch", "POST /v1/batch", s.authorized(anyone, s.handleBatch))
+	s.handle("/stats/users", "GET /v1/stats/users", s.authorized(anyone, s.handleUserStats))
+	s.handle("/stats/teams", "GET /v1/stats/teams", s.authorized(anyone, s.handleTeamStats))
+	s.handle("/stats/fairness", "GET /v1/stats/fairness", s.authorized(anyone, s.handleFairness))
+	s.handle("/stats/assignments", "GET /v1/stats/assignments", s.authorized(anyone, s.handleAssignmentHistory))
+	s.handle("/openapi.json", "", s.handleOpenAPI)
+	s.handle("/healthz", "", s.handleHealthz)
+	s.handle("/readyz", "", s.handleReadyz)
+	s.handle("/version", "", s.handleVersion)
+	if s.metrics != nil {
+		s.handle("/metrics", "", s.authorized(adminOnly, s.handleMetrics))
+	}
+
+	if len(s.githubSecrets) > 0 {
+		s.handle("/integrations/github", "POST /v1/integrations/github", s.audited("integrations.github", auditTarget{
+			entityType: entityPullRequest,
+			entityID:   githubPullRequestID,
+			actor:      "integration:github",
+		}, s.handleGitHubWebhook))
+	}
+	if len(s.gitlabTokens) > 0 {
+		s.handle("/integrations/gitlab", "POST /v1/integrations/gitlab", s.audited("integrations.gitlab", auditTarget{
+			entityType: entityPullRequest,
+			entityID:   gitlabPullRequestID,
+			actor:      "integration:gitlab",
+		}, s.handleGitLabWebhook))
+	}
+
+	if s.audit != nil {
+		s.handle("/audit", "GET /v1/audit", s.authorized(adminOnly, s.handleAudit))
+	}
+
+	if s.events != nil {
+		s.handle("/events/stream", "GET /v1/events", s.authorized(anyone, s.handleEventStream))
+	}
+
+	if s.tokens != nil {
+		token := auditTarget{entityType: entityToken, entityID: jsonField("user_id")}
+		s.handle("/auth/tokens", "POST /v1/tokens",
+			s.audited("auth.issueToken", token, s.authorized(adminOnly, s.handleIssueToken)))
+		s.handle("/auth/tokens/revoke", "POST /v1/tokens/revoke",
+			s.audited("auth.revokeToken", token, s.authorized(adminOnly, s.handleRevokeToken)))
+	}
+
+	if s.webhooks != nil {
+		hook := auditTarget{entityType: entityWebhook, entityID: jsonField("webhook_id")}
+		deliveries := s.authorized(adminOnly, s.handleWebhookDeliveries)
+		s.handle("/webhooks/add", "POST /v1/webhooks",
+			s.audited("webhooks.add", hook, s.authorized(adminOnly, s.handleWebhookAdd)))
+		s.handle("/webhooks/list", "GET /v1/webhooks", s.authorized(adminOnly, s.handleWebhookList))
+		s.handle("/webhooks/remove", "DELETE /v1/webhooks/{id}",
+			s.audited("webhooks.remove", hook, s.authorized(adminOnly, s.handleWebhookRemove)), param("id", "webhook_id"))
+		s.handle("/webhooks/deliveries", "GET /v1/webhooks/deliveries", deliveries)
+		s.handle("", "GET /v1/webhooks/{id}/deliveries", deliveries, param("id", "webhook_id"))
+	}
 }
 
 func (s *Server) handleTeamAdd(w http.ResponseWriter, r *http.Request) {
@@ -156,7 +411,7 @@ func (s *Server) handleTeamAdd(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	team, err := s.store.CreateTeam(req.TeamName, members)
+	team, err := s.tenant(r).CreateTeam(req.TeamName, members)
 	if err != nil {
 		if errors.Is(err, store.ErrTeamExists) {
 			writeError(w, http.StatusBadRequest, "TEAM_EXISTS", "team_name already exists")
@@ -167,6 +422,7 @@ func (s *Server) handleTeamAdd(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := teamAddResponse{Team: makeTeamPayload(team)}
+	setETag(w, team.Version)
 	writeJSON(w, http.StatusCreated, resp)
 }
 
@@ -182,7 +438,7 @@ func (s *Server) handleTeamGet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	team, err := s.store.GetTeam(teamName)
+	team, err := s.tenant(r).GetTeam(teamName)
 	if err != nil {
 		if errors.Is(err, store.ErrTeamNotFound) {
 			writeNotFound(w)
@@ -192,9 +448,52 @@ func (s *Server) handleTeamGet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, team.Version)
 	writeJSON(w, http.StatusOK, makeTeamPayload(team))
 }
 
+func (s *Server) handleSetTeamLead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	var req setTeamLeadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "invalid JSON payload")
+		return
+	}
+
+	if req.TeamName == "" || req.UserID == "" {
+		badRequest(w, "team_name and user_id are required")
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	team, err := s.tenant(r).SetTeamLeadIf(req.TeamName, req.UserID, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrVersionMismatch):
+			preconditionFailed(w)
+		case errors.Is(err, store.ErrTeamNotFound), errors.Is(err, store.ErrUserNotFound):
+			writeNotFound(w)
+		case errors.Is(err, store.ErrUserNotInTeam):
+			writeError(w, http.StatusConflict, "NOT_IN_TEAM", "user is not a member of this team")
+		default:
+			writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+		}
+		return
+	}
+
+	resp := setTeamLeadResponse{Team: makeTeamPayload(team)}
+	setETag(w, team.Version)
+	writeJSON(w, http.StatusOK, resp)
+}
+
 func (s *Server) handleSetIsActive(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		methodNotAllowed(w)
@@ -212,17 +511,28 @@ func (s *Server) handleSetIsActive(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := s.store.SetUserActive(req.UserID, *req.IsActive)
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	user, teamVersion, err := s.tenant(r).SetUserActiveIf(req.UserID, *req.IsActive, version)
 	if err != nil {
-		if errors.Is(err, store.ErrUserNotFound) {
+		switch {
+		case errors.Is(err, store.ErrVersionMismatch):
+			preconditionFailed(w)
+		case errors.Is(err, store.ErrUserNotFound):
 			writeNotFound(w)
-			return
+		default:
+			writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
 		}
-		writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
 		return
 	}
 
 	resp := setIsActiveResponse{User: makeUserPayload(user)}
+	if teamVersion != 0 {
+		setETag(w, teamVersion)
+	}
 	writeJSON(w, http.StatusOK, resp)
 }
 
@@ -243,7 +553,7 @@ func (s *Server) handleCreatePullRequest(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	pr, err := s.store.CreatePullRequest(store.CreatePullRequestInput{
+	pr, err := s.tenant(r).CreatePullRequest(store.CreatePullRequestInput{
 		ID:       req.PullRequestID,
 		Name:     req.PullRequestName,
 		AuthorID: req.AuthorID,
@@ -261,9 +571,81 @@ func (s *Server) handleCreatePullRequest(w http.ResponseWriter, r *http.Request)
 	}
 
 	resp := createPullRequestResponse{PR: makePullRequestResponse(pr)}
+	setETag(w, pr.Version)
 	writeJSON(w, http.StatusCreated, resp)
 }
 
+func (s *Server) handleGetPullRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		badRequest(w, "pull_request_id is required")
+		return
+	}
+
+	pr, err := s.tenant(r).GetPullRequest(prID)
+	if err != nil {
+		if errors.Is(err, store.ErrPullRequestNotFound) {
+			writeNotFound(w)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+		return
+	}
+
+	setETag(w, pr.Version)
+	writeJSON(w, http.StatusOK, getPullRequestResponse{PR: makePullRequestResponse(pr)})
+}
+
+func (s *Server) handlePreviewPullRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	var req createPullRequestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "invalid JSON payload")
+		return
+	}
+
+	if req.PullRequestID == "" || req.PullRequestName == "" || req.AuthorID == "" {
+		badRequest(w, "pull_request_id, pull_request_name, and author_id are required")
+		return
+	}
+
+	preview, err := s.tenant(r).PreviewPullRequest(store.CreatePullRequestInput{
+		ID:       req.PullRequestID,
+		Name:     req.PullRequestName,
+		AuthorID: req.AuthorID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrPullRequestExists):
+			writeError(w, http.StatusConflict, "PR_EXISTS", "pull request id already exists")
+		case errors.Is(err, store.ErrUserNotFound), errors.Is(err, store.ErrTeamNotFound):
+			writeNotFound(w)
+		default:
+			writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+		}
+		return
+	}
+
+	resp := previewPullRequestResponse{
+		PullRequestID:     preview.ID,
+		AuthorID:          preview.AuthorID,
+		Strategy:          preview.Strategy,
+		ProposedReviewers: append([]string{}, preview.Reviewers...),
+		Candidates:        append([]string{}, preview.Candidates...),
+		Excluded:          makeCandidateExclusionPayloads(preview.Excluded),
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
 func (s *Server) handleMergePullRequest(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		methodNotAllowed(w)
@@ -281,17 +663,28 @@ func (s *Server) handleMergePullRequest(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	pr, err := s.store.MergePullRequest(req.PullRequestID)
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	pr, err := s.tenant(r).MergePullRequestIf(req.PullRequestID, version)
 	if err != nil {
-		if errors.Is(err, store.ErrPullRequestNotFound) {
+		switch {
+		case errors.Is(err, store.ErrVersionMismatch):
+			preconditionFailed(w)
+		case errors.Is(err, store.ErrPullRequestNotFound):
 			writeNotFound(w)
-			return
+		case errors.Is(err, store.ErrPullRequestClosed):
+			writeError(w, http.StatusConflict, "PR_CLOSED", "cannot merge closed PR")
+		default:
+			writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
 		}
-		writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
 		return
 	}
 
 	resp := mergePullRequestResponse{PR: makePullRequestResponse(pr)}
+	setETag(w, pr.Version)
 	writeJSON(w, http.StatusOK, resp)
 }
 
@@ -312,17 +705,44 @@ func (s *Server) handleReassign(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := s.store.ReassignReviewer(req.PullRequestID, req.OldUserID)
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	preferred := make([]string, 0, len(req.PreferredUserIDs)+1)
+	if req.NewUserID != "" {
+		preferred = append(preferred, req.NewUserID)
+	}
+	for _, id := range req.PreferredUserIDs {
+		if id != "" {
+			preferred = append(preferred, id)
+		}
+	}
+
+	result, err := s.tenant(r).ReassignReviewerIf(store.ReassignReviewerInput{
+		PullRequestID:  req.PullRequestID,
+		OldReviewerID:  req.OldUserID,
+		Preferred:      preferred,
+		FallbackToAuto: req.FallbackToAuto,
+	}, version)
+	s.countReassignFailure(r, err)
 	if err != nil {
 		switch {
+		case errors.Is(err, store.ErrVersionMismatch):
+			preconditionFailed(w)
 		case errors.Is(err, store.ErrPullRequestNotFound), errors.Is(err, store.ErrUserNotFound), errors.Is(err, store.ErrTeamNotFound):
 			writeNotFound(w)
 		case errors.Is(err, store.ErrPullRequestMerged):
 			writeError(w, http.StatusConflict, "PR_MERGED", "cannot reassign on merged PR")
+		case errors.Is(err, store.ErrPullRequestClosed):
+			writeError(w, http.StatusConflict, "PR_CLOSED", "cannot reassign on closed PR")
 		case errors.Is(err, store.ErrReviewerNotAssigned):
 			writeError(w, http.StatusConflict, "NOT_ASSIGNED", "reviewer is not assigned to this PR")
 		case errors.Is(err, store.ErrNoReplacementCandidate):
 			writeError(w, http.StatusConflict, "NO_CANDIDATE", "no active replacement candidate in team")
+		case errors.Is(err, store.ErrPreferredReviewerIneligible):
+			writeError(w, http.StatusConflict, "PREFERRED_INELIGIBLE", "none of the preferred reviewers can be assigned")
 		default:
 			writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
 		}
@@ -330,6 +750,138 @@ func (s *Server) handleReassign(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := reassignResponse{PR: makePullRequestResponse(result.PR), ReplacedBy: result.ReplacedBy}
+	setETag(w, result.PR.Version)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleAddReviewer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	var req reviewerChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "invalid JSON payload")
+		return
+	}
+
+	if req.PullRequestID == "" || req.UserID == "" {
+		badRequest(w, "pull_request_id and user_id are required")
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	pr, err := s.tenant(r).AddReviewerIf(req.PullRequestID, req.UserID, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrVersionMismatch):
+			preconditionFailed(w)
+		case errors.Is(err, store.ErrPullRequestNotFound), errors.Is(err, store.ErrUserNotFound):
+			writeNotFound(w)
+		case errors.Is(err, store.ErrPullRequestMerged):
+			writeError(w, http.StatusConflict, "PR_MERGED", "cannot change reviewers on merged PR")
+		case errors.Is(err, store.ErrPullRequestClosed):
+			writeError(w, http.StatusConflict, "PR_CLOSED", "cannot change reviewers on closed PR")
+		case errors.Is(err, store.ErrReviewerIsAuthor):
+			writeError(w, http.StatusConflict, "AUTHOR_REVIEWER", "author cannot review own PR")
+		case errors.Is(err, store.ErrReviewerInactive):
+			writeError(w, http.StatusConflict, "USER_INACTIVE", "reviewer is not active")
+		case errors.Is(err, store.ErrReviewerAlreadyAssigned):
+			writeError(w, http.StatusConflict, "ALREADY_ASSIGNED", "reviewer is already assigned to this PR")
+		case errors.Is(err, store.ErrReviewerAtCapacity):
+			writeError(w, http.StatusConflict, "AT_CAPACITY", "reviewer has reached the limit of open reviews")
+		default:
+			writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+		}
+		return
+	}
+
+	resp := reviewerChangeResponse{PR: makePullRequestResponse(pr)}
+	setETag(w, pr.Version)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleRemoveReviewer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	var req reviewerChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "invalid JSON payload")
+		return
+	}
+
+	if req.PullRequestID == "" || req.UserID == "" {
+		badRequest(w, "pull_request_id and user_id are required")
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	pr, err := s.tenant(r).RemoveReviewerIf(req.PullRequestID, req.UserID, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrVersionMismatch):
+			preconditionFailed(w)
+		case errors.Is(err, store.ErrPullRequestNotFound):
+			writeNotFound(w)
+		case errors.Is(err, store.ErrPullRequestMerged):
+			writeError(w, http.StatusConflict, "PR_MERGED", "cannot change reviewers on merged PR")
+		case errors.Is(err, store.ErrPullRequestClosed):
+			writeError(w, http.StatusConflict, "PR_CLOSED", "cannot change reviewers on closed PR")
+		case errors.Is(err, store.ErrReviewerNotAssigned):
+			writeError(w, http.StatusConflict, "NOT_ASSIGNED", "reviewer is not assigned to this PR")
+		default:
+			writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+		}
+		return
+	}
+
+	resp := reviewerChangeResponse{PR: makePullRequestResponse(pr)}
+	setETag(w, pr.Version)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleAssignmentExplain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		badRequest(w, "pull_request_id is required")
+		return
+	}
+
+	history, err := s.tenant(r).ExplainAssignments(prID)
+	if err != nil {
+		if errors.Is(err, store.ErrPullRequestNotFound) {
+			writeNotFound(w)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+		return
+	}
+
+	resp := assignmentExplainResponse{
+		PullRequestID: prID,
+		Assignments:   make([]assignmentExplanationPayload, 0, len(history)),
+	}
+	for _, e := range history {
+		resp.Assignments = append(resp.Assignments, makeAssignmentExplanationPayload(e))
+	}
+
 	writeJSON(w, http.StatusOK, resp)
 }
 
@@ -345,7 +897,7 @@ func (s *Server) handleUserReviews(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	prs, err := s.store.ListPullRequestsByReviewer(userID)
+	prs, err := s.tenant(r).ListPullRequestsByReviewer(userID)
 	if err != nil {
 		if errors.Is(err, store.ErrUserNotFound) {
 			writeNotFound(w)
@@ -374,7 +926,9 @@ func (s *Server) handleUserReviews(w http.ResponseWriter, r *http.Request) {
 func makeTeamPayload(team *store.Team) teamPayload {
 	payload := teamPayload{
 		TeamName: team.Name,
+		LeadID:   team.LeadID,
 		Members:  make([]teamMemberPayload, 0, len(team.Members)),
+		Version:  team.Version,
 	}
 	for _, member := range team.Members {
 		payload.Members = append(payload.Members, teamMemberPayload{
@@ -402,6 +956,7 @@ func makePullRequestResponse(pr *store.PullRequest) pullRequestResponse {
 		AuthorID:          pr.AuthorID,
 		Status:            pr.Status,
 		AssignedReviewers: append([]string(nil), pr.AssignedReviewers...),
+		Version:           pr.Version,
 	}
 
 	if !pr.CreatedAt.IsZero() {
@@ -412,10 +967,39 @@ func makePullRequestResponse(pr *store.PullRequest) pullRequestResponse {
 		merged := pr.MergedAt.Format(time.RFC3339)
 		resp.MergedAt = &merged
 	}
+	if pr.ClosedAt != nil {
+		closed := pr.ClosedAt.Format(time.RFC3339)
+		resp.ClosedAt = &closed
+	}
 
 	return resp
 }
 
+func makeAssignmentExplanationPayload(e store.AssignmentExplanation) assignmentExplanationPayload {
+	payload := assignmentExplanationPayload{
+		Kind:       e.Kind,
+		Strategy:   e.Strategy,
+		At:         e.At.Format(time.RFC3339),
+		Candidates: append([]string{}, e.Candidates...),
+		Excluded:   makeCandidateExclusionPayloads(e.Excluded),
+		Selected:   append([]string{}, e.Selected...),
+		Replaced:   e.Replaced,
+		TeamName:   e.TeamName,
+	}
+	return payload
+}
+
+func makeCandidateExclusionPayloads(excluded []store.CandidateExclusion) []candidateExclusionPayload {
+	payload := make([]candidateExclusionPayload, 0, len(excluded))
+	for _, ex := range excluded {
+		payload = append(payload, candidateExclusionPayload{
+			UserID: ex.UserID,
+			Reason: ex.Reason,
+		})
+	}
+	return payload
+}
+
 func writeJSON(w http.ResponseWriter, status int, payload any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -428,6 +1012,7 @@ func writeError(w http.ResponseWriter, status int, code, message string) {
 	body := errorBody{}
 	body.Error.Code = code
 	body.Error.Message = message
+	body.Error.RequestID = w.Header().Get(requestIDHeader)
 	_ = json.NewEncoder(w).Encode(body)
 }
 
@@ -0,0 +1,302 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ToxicSozo/GoDraw/internal/audit"
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+func newTestServer(t *testing.T, opts ...Option) (*Server, *store.Orgs) {
+	t.Helper()
+	orgs := store.NewOrgs(store.WithSelectionMode(store.SelectionDeterministic))
+	return New(orgs, opts...), orgs
+}
+
+// do sends a request through h. headers alternate names and values.
+func do(t *testing.T, h http.Handler, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body errorBody
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode error body %q: %v", w.Body.String(), err)
+	}
+	return body.Error.Code
+}
+
+const teamBackend = `{"team_name":"backend","members":[
+	{"user_id":"u1","username":"alice","is_active":true},
+	{"user_id":"u2","username":"bob","is_active":true},
+	{"user_id":"u3","username":"carol","is_active":true},
+	{"user_id":"u4","username":"dave","is_active":true}]}`
+
+func mustDo(t *testing.T, h http.Handler, want int, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := do(t, h, method, path, body, headers...)
+	if w.Code != want {
+		t.Fatalf("%s %s = %d %s, want %d", method, path, w.Code, w.Body.String(), want)
+	}
+	return w
+}
+
+type prEnvelope struct {
+	PR struct {
+		ID                string   `json:"pull_request_id"`
+		AuthorID          string   `json:"author_id"`
+		Status            string   `json:"status"`
+		AssignedReviewers []string `json:"assigned_reviewers"`
+		Version           uint64   `json:"version"`
+	} `json:"pr"`
+}
+
+func decodePR(t *testing.T, w *httptest.ResponseRecorder) prEnvelope {
+	t.Helper()
+	var env prEnvelope
+	if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	return env
+}
+
+// createPR opens a pull request by u1 in team backend.
+func createPR(t *testing.T, h http.Handler, id string, headers ...string) prEnvelope {
+	t.Helper()
+	w := mustDo(t, h, http.StatusCreated, http.MethodPost, "/pullRequest/create",
+		`{"pull_request_id":"`+id+`","pull_request_name":"`+id+`","author_id":"u1"}`, headers...)
+	return decodePR(t, w)
+}
+
+func TestAssignmentExplain(t *testing.T) {
+	s, _ := newTestServer(t)
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+	mustDo(t, s, http.StatusOK, http.MethodPost, "/users/setIsActive", `{"user_id":"u4","is_active":false}`)
+	pr := createPR(t, s, "pr-1")
+
+	mustDo(t, s, http.StatusNotFound, http.MethodGet, "/pullRequest/assignmentExplain?pull_request_id=missing", "")
+	mustDo(t, s, http.StatusBadRequest, http.MethodGet, "/pullRequest/assignmentExplain", "")
+
+	for _, path := range []string{"/pullRequest/assignmentExplain?pull_request_id=pr-1", "/v1/pull-requests/pr-1/assignments"} {
+		w := mustDo(t, s, http.StatusOK, http.MethodGet, path, "")
+		var resp struct {
+			PullRequestID string `json:"pull_request_id"`
+			Assignments   []struct {
+				Kind       string   `json:"kind"`
+				Strategy   string   `json:"strategy"`
+				At         string   `json:"at"`
+				Candidates []string `json:"candidates"`
+				Excluded   []struct {
+					UserID string `json:"user_id"`
+					Reason string `json:"reason"`
+				} `json:"excluded"`
+				Selected []string `json:"selected"`
+				TeamName string   `json:"team_name"`
+			} `json:"assignments"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode %q: %v", w.Body.String(), err)
+		}
+		if resp.PullRequestID != "pr-1" || len(resp.Assignments) != 1 {
+			t.Fatalf("%s: response = %s, want one assignment of pr-1", path, w.Body.String())
+		}
+		a := resp.Assignments[0]
+		if a.Kind != store.AssignmentCreate || a.Strategy != store.StrategyDeterministic || a.At == "" || a.TeamName != "backend" ||
+			fmt.Sprint(a.Candidates) != "[u2 u3]" || fmt.Sprint(a.Selected) != fmt.Sprint(pr.PR.AssignedReviewers) {
+			t.Errorf("%s: assignment = %+v", path, a)
+		}
+		if fmt.Sprint(a.Excluded) != "[{u1 author} {u4 inactive}]" {
+			t.Errorf("%s: excluded = %v, want u1 as author and u4 as inactive", path, a.Excluded)
+		}
+	}
+}
+
+func TestAddReviewerAtCapacity(t *testing.T) {
+	s := New(store.NewOrgs(store.WithSelectionMode(store.SelectionDeterministic), store.WithMaxOpenReviews(1)))
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+	busy := createPR(t, s, "pr-1").PR.AssignedReviewers
+	createPR(t, s, "pr-2")
+
+	w := do(t, s, http.MethodPost, "/pullRequest/addReviewer", `{"pull_request_id":"pr-2","user_id":"`+busy[0]+`"}`)
+	if w.Code != http.StatusConflict || errorCode(t, w) != "AT_CAPACITY" {
+		t.Fatalf("add of a reviewer at capacity = %d %s, want 409 AT_CAPACITY", w.Code, w.Body.String())
+	}
+
+	body := mustDo(t, s, http.StatusOK, http.MethodGet, "/pullRequest/assignmentExplain?pull_request_id=pr-2", "").Body.String()
+	for _, id := range busy {
+		if !strings.Contains(body, `{"user_id":"`+id+`","reason":"at_capacity"}`) {
+			t.Errorf("explanation of pr-2 does not exclude %s at capacity: %s", id, body)
+		}
+	}
+}
+
+func TestReviewerChanges(t *testing.T) {
+	const teamFrontend = `{"team_name":"frontend","members":[
+		{"user_id":"f1","username":"erin","is_active":true},
+		{"user_id":"f2","username":"frank","is_active":false}]}`
+
+	tests := []struct {
+		name     string
+		path     string
+		merge    bool
+		user     func(reviewer, idle string) string
+		wantCode int
+		wantErr  string
+	}{
+		{"add idle member", "/pullRequest/addReviewer", false, func(_, idle string) string { return idle }, http.StatusOK, ""},
+		{"add member of another team", "/pullRequest/addReviewer", false, func(string, string) string { return "f1" }, http.StatusOK, ""},
+		{"add to merged", "/pullRequest/addReviewer", true, func(_, idle string) string { return idle }, http.StatusConflict, "PR_MERGED"},
+		{"add author", "/pullRequest/addReviewer", false, func(string, string) string { return "u1" }, http.StatusConflict, "AUTHOR_REVIEWER"},
+		{"add inactive", "/pullRequest/addReviewer", false, func(string, string) string { return "f2" }, http.StatusConflict, "USER_INACTIVE"},
+		{"add duplicate", "/pullRequest/addReviewer", false, func(reviewer, _ string) string { return reviewer }, http.StatusConflict, "ALREADY_ASSIGNED"},
+		{"add unknown user", "/pullRequest/addReviewer", false, func(string, string) string { return "missing" }, http.StatusNotFound, "NOT_FOUND"},
+		{"remove reviewer", "/pullRequest/removeReviewer", false, func(reviewer, _ string) string { return reviewer }, http.StatusOK, ""},
+		{"remove from merged", "/pullRequest/removeReviewer", true, func(reviewer, _ string) string { return reviewer }, http.StatusConflict, "PR_MERGED"},
+		{"remove unassigned", "/pullRequest/removeReviewer", false, func(_, idle string) string { return idle }, http.StatusConflict, "NOT_ASSIGNED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestServer(t)
+			mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+			mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamFrontend)
+			pr := createPR(t, s, "pr-1").PR
+			var idle string
+			for _, id := range []string{"u2", "u3", "u4"} {
+				if reviewerIndexOf(pr.AssignedReviewers, id) == -1 {
+					idle = id
+				}
+			}
+			if tt.merge {
+				pr = decodePR(t, mustDo(t, s, http.StatusOK, http.MethodPost, "/pullRequest/merge", `{"pull_request_id":"pr-1"}`)).PR
+			}
+			userID := tt.user(pr.AssignedReviewers[0], idle)
+
+			w := do(t, s, http.MethodPost, tt.path, `{"pull_request_id":"pr-1","user_id":"`+userID+`"}`)
+			if w.Code != tt.wantCode {
+				t.Fatalf("%s %s = %d %s, want %d", tt.path, userID, w.Code, w.Body.String(), tt.wantCode)
+			}
+			after := decodePR(t, mustDo(t, s, http.StatusOK, http.MethodGet, "/pullRequest/get?pull_request_id=pr-1", "")).PR
+			if tt.wantErr != "" {
+				if code := errorCode(t, w); code != tt.wantErr {
+					t.Errorf("code = %s, want %s", code, tt.wantErr)
+				}
+				if after.Version != pr.Version || fmt.Sprint(after.AssignedReviewers) != fmt.Sprint(pr.AssignedReviewers) {
+					t.Errorf("rejected change left pr-1 at version %d with %v, want %d with %v", after.Version, after.AssignedReviewers, pr.Version, pr.AssignedReviewers)
+				}
+				return
+			}
+
+			got := decodePR(t, w).PR
+			if got.Version != pr.Version+1 || after.Version != got.Version {
+				t.Errorf("version = %d (stored %d), want %d", got.Version, after.Version, pr.Version+1)
+			}
+			if etag := w.Header().Get("ETag"); etag != fmt.Sprintf("%q", fmt.Sprint(got.Version)) {
+				t.Errorf("ETag = %s, want version %d", etag, got.Version)
+			}
+			assigned := reviewerIndexOf(after.AssignedReviewers, userID) != -1
+			if adding := tt.path == "/pullRequest/addReviewer"; assigned != adding {
+				t.Errorf("reviewers = %v after %s %s", after.AssignedReviewers, tt.path, userID)
+			}
+		})
+	}
+}
+
+func TestReassignPreferredUsers(t *testing.T) {
+	s, _ := newTestServer(t)
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+	pr := createPR(t, s, "pr-1").PR
+	old, kept := pr.AssignedReviewers[0], pr.AssignedReviewers[1]
+	var idle string
+	for _, id := range []string{"u2", "u3", "u4"} {
+		if reviewerIndexOf(pr.AssignedReviewers, id) == -1 {
+			idle = id
+		}
+	}
+
+	reassign := func(preferred string, fallback bool) *httptest.ResponseRecorder {
+		return do(t, s, http.MethodPost, "/pullRequest/reassign", fmt.Sprintf(
+			`{"pull_request_id":"pr-1","old_user_id":%q,"preferred_user_ids":[%s],"fallback_to_auto":%v}`, old, preferred, fallback))
+	}
+
+	w := reassign(fmt.Sprintf(`"u1",%q`, kept), false)
+	if w.Code != http.StatusConflict || errorCode(t, w) != "PREFERRED_INELIGIBLE" {
+		t.Fatalf("only ineligible preferred users = %d %s, want 409 PREFERRED_INELIGIBLE", w.Code, w.Body.String())
+	}
+
+	w = reassign(fmt.Sprintf(`"u1",%q`, idle), false)
+	var resp reassignResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("reassign = %d %s", w.Code, w.Body.String())
+	}
+	if resp.ReplacedBy != idle {
+		t.Errorf("replaced by %s, want the eligible preferred user %s", resp.ReplacedBy, idle)
+	}
+
+	// The reviewer who was replaced is the only candidate left.
+	old = idle
+	w = reassign(`"u1"`, true)
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("reassign with fallback = %d %s", w.Code, w.Body.String())
+	}
+	if resp.ReplacedBy != pr.AssignedReviewers[0] {
+		t.Errorf("fallback replaced by %s, want %s", resp.ReplacedBy, pr.AssignedReviewers[0])
+	}
+}
+
+func TestPreviewHasNoSideEffects(t *testing.T) {
+	log := audit.New(nil)
+	s, orgs := newTestServer(t, WithAudit(log))
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+	team := mustDo(t, s, http.StatusOK, http.MethodGet, "/team/get?team_name=backend", "").Header().Get("ETag")
+	entries := len(log.Query(audit.Filter{Org: store.DefaultOrg}))
+	if entries == 0 {
+		t.Fatalf("team.add was not audited")
+	}
+
+	events := make(chan string, 8)
+	t.Cleanup(orgs.Subscribe(func(e store.Event) { events <- e.Name() }))
+
+	body := `{"pull_request_id":"pr-1","pull_request_name":"pr-1","author_id":"u1"}`
+	for _, path := range []string{"/pullRequest/preview", "/v1/pull-requests/preview"} {
+		mustDo(t, s, http.StatusOK, http.MethodPost, path, body)
+	}
+
+	mustDo(t, s, http.StatusNotFound, http.MethodGet, "/pullRequest/get?pull_request_id=pr-1", "")
+	if got := mustDo(t, s, http.StatusOK, http.MethodGet, "/team/get?team_name=backend", "").Header().Get("ETag"); got != team {
+		t.Errorf("team ETag = %s after preview, want %s", got, team)
+	}
+	if got := len(log.Query(audit.Filter{Org: store.DefaultOrg})); got != entries {
+		t.Errorf("preview added %d audit entries", got-entries)
+	}
+
+	// A marker write after the previews proves they emitted nothing.
+	mustDo(t, s, http.StatusOK, http.MethodPost, "/users/setIsActive", `{"user_id":"u4","is_active":false}`)
+	select {
+	case name := <-events:
+		if name != "user.activity_changed" {
+			t.Errorf("first event after preview = %s, want the marker user.activity_changed", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no event for the marker write")
+	}
+
+	// Creating the previewed pull request still works.
+	createPR(t, s, "pr-1")
+}
@@ -0,0 +1,177 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+type setReviewSLARequest struct {
+	TeamName string  `json:"team_name"`
+	SLAHours float64 `json:"sla_hours"`
+}
+
+type setReviewSLAResponse struct {
+	TeamName string  `json:"team_name"`
+	SLAHours float64 `json:"sla_hours"`
+}
+
+type submitReviewRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	UserID        string `json:"user_id"`
+}
+
+type submitReviewResponse struct {
+	PR pullRequestResponse `json:"pr"`
+}
+
+type overdueReviewerPayload struct {
+	UserID         string `json:"user_id"`
+	AssignedAt     string `json:"assigned_at"`
+	Deadline       string `json:"deadline"`
+	WaitingSeconds int64  `json:"waiting_seconds"`
+	OverdueSeconds int64  `json:"overdue_seconds"`
+}
+
+type overduePullRequestPayload struct {
+	PullRequestID   string                   `json:"pull_request_id"`
+	PullRequestName string                   `json:"pull_request_name"`
+	AuthorID        string                   `json:"author_id"`
+	TeamName        string                   `json:"team_name"`
+	SLAHours        float64                  `json:"sla_hours"`
+	Reviewers       []overdueReviewerPayload `json:"reviewers"`
+}
+
+type overdueResponse struct {
+	PullRequests []overduePullRequestPayload `json:"pull_requests"`
+}
+
+func (s *Server) handleSetReviewSLA(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	var req setReviewSLARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "invalid JSON payload")
+		return
+	}
+
+	if req.TeamName == "" || req.SLAHours <= 0 {
+		badRequest(w, "team_name and positive sla_hours are required")
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	team, err := s.tenant(r).SetTeamReviewSLAIf(req.TeamName, time.Duration(req.SLAHours*float64(time.Hour)), version)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrVersionMismatch):
+			preconditionFailed(w)
+		case errors.Is(err, store.ErrTeamNotFound):
+			writeNotFound(w)
+		case errors.Is(err, store.ErrInvalidSLA):
+			badRequest(w, "team_name and positive sla_hours are required")
+		default:
+			writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+		}
+		return
+	}
+
+	setETag(w, team.Version)
+	writeJSON(w, http.StatusOK, setReviewSLAResponse{TeamName: team.Name, SLAHours: team.ReviewSLA.Hours()})
+}
+
+func (s *Server) handleSubmitReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	var req submitReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "invalid JSON payload")
+		return
+	}
+
+	if req.PullRequestID == "" || req.UserID == "" {
+		badRequest(w, "pull_request_id and user_id are required")
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	pr, err := s.tenant(r).SubmitReviewIf(req.PullRequestID, req.UserID, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrVersionMismatch):
+			preconditionFailed(w)
+		case errors.Is(err, store.ErrPullRequestNotFound):
+			writeNotFound(w)
+		case errors.Is(err, store.ErrPullRequestMerged):
+			writeError(w, http.StatusConflict, "PR_MERGED", "cannot review merged PR")
+		case errors.Is(err, store.ErrPullRequestClosed):
+			writeError(w, http.StatusConflict, "PR_CLOSED", "cannot review closed PR")
+		case errors.Is(err, store.ErrReviewerNotAssigned):
+			writeError(w, http.StatusConflict, "NOT_ASSIGNED", "reviewer is not assigned to this PR")
+		default:
+			writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+		}
+		return
+	}
+
+	setETag(w, pr.Version)
+	writeJSON(w, http.StatusOK, submitReviewResponse{PR: makePullRequestResponse(pr)})
+}
+
+func (s *Server) handleOverdue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	overdue, err := s.tenant(r).ListOverdueReviews(r.URL.Query().Get("team_name"))
+	if err != nil {
+		if errors.Is(err, store.ErrTeamNotFound) {
+			writeNotFound(w)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+		return
+	}
+
+	resp := overdueResponse{PullRequests: make([]overduePullRequestPayload, 0, len(overdue))}
+	for _, pr := range overdue {
+		payload := overduePullRequestPayload{
+			PullRequestID:   pr.PullRequestID,
+			PullRequestName: pr.PullRequestName,
+			AuthorID:        pr.AuthorID,
+			TeamName:        pr.TeamName,
+			SLAHours:        pr.ReviewSLA.Hours(),
+			Reviewers:       make([]overdueReviewerPayload, 0, len(pr.Reviewers)),
+		}
+		for _, rv := range pr.Reviewers {
+			payload.Reviewers = append(payload.Reviewers, overdueReviewerPayload{
+				UserID:         rv.UserID,
+				AssignedAt:     rv.AssignedAt.Format(time.RFC3339),
+				Deadline:       rv.Deadline.Format(time.RFC3339),
+				WaitingSeconds: int64(rv.Waiting / time.Second),
+				OverdueSeconds: int64((rv.Waiting - pr.ReviewSLA) / time.Second),
+			})
+		}
+		resp.PullRequests = append(resp.PullRequests, payload)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
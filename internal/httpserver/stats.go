@@ -0,0 +1,284 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+type reviewStatsPayload struct {
+	Assigned int `json:"assigned"`
+	Open     int `json:"open"`
+	Merged   int `json:"merged"`
+	// MedianTimeToMergeSeconds is null when nothing was merged.
+	MedianTimeToMergeSeconds *int64 `json:"median_time_to_merge_seconds"`
+	Reassigned               int    `json:"reassigned"`
+}
+
+type userStatsPayload struct {
+	UserID   string `json:"user_id"`
+	TeamName string `json:"team_name"`
+	reviewStatsPayload
+}
+
+type teamStatsPayload struct {
+	TeamName string `json:"team_name"`
+	reviewStatsPayload
+}
+
+type userStatsResponse struct {
+	From  *string            `json:"from,omitempty"`
+	To    *string            `json:"to,omitempty"`
+	Users []userStatsPayload `json:"users"`
+}
+
+type teamStatsResponse struct {
+	From  *string            `json:"from,omitempty"`
+	To    *string            `json:"to,omitempty"`
+	Teams []teamStatsPayload `json:"teams"`
+}
+
+type memberFairnessPayload struct {
+	UserID      string  `json:"user_id"`
+	Assignments int     `json:"assignments"`
+	Share       float64 `json:"share"`
+	Outlier     string  `json:"outlier,omitempty"`
+}
+
+type teamFairnessPayload struct {
+	TeamName    string                  `json:"team_name"`
+	Assignments int                     `json:"assignments"`
+	Gini        float64                 `json:"gini"`
+	Members     []memberFairnessPayload `json:"members"`
+}
+
+type fairnessResponse struct {
+	From  *string               `json:"from,omitempty"`
+	To    *string               `json:"to,omitempty"`
+	Teams []teamFairnessPayload `json:"teams"`
+}
+
+type assignmentRecordPayload struct {
+	PullRequestID string `json:"pull_request_id"`
+	UserID        string `json:"user_id"`
+	TeamName      string `json:"team_name"`
+	Kind          string `json:"kind"`
+	At            string `json:"at"`
+}
+
+type assignmentHistoryResponse struct {
+	From        *string                   `json:"from,omitempty"`
+	To          *string                   `json:"to,omitempty"`
+	Assignments []assignmentRecordPayload `json:"assignments"`
+}
+
+func (s *Server) handleUserStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	window, ok := parseWindow(w, r)
+	if !ok {
+		return
+	}
+	teamName, ok := s.statsTeam(w, r)
+	if !ok {
+		return
+	}
+
+	resp := userStatsResponse{Users: make([]userStatsPayload, 0)}
+	resp.From, resp.To = formatWindow(window)
+	for _, stats := range s.tenant(r).UserStats(window) {
+		if teamName != "" && stats.TeamName != teamName {
+			continue
+		}
+		resp.Users = append(resp.Users, userStatsPayload{
+			UserID:             stats.UserID,
+			TeamName:           stats.TeamName,
+			reviewStatsPayload: makeReviewStatsPayload(stats.ReviewStats),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleTeamStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	window, ok := parseWindow(w, r)
+	if !ok {
+		return
+	}
+	teamName, ok := s.statsTeam(w, r)
+	if !ok {
+		return
+	}
+
+	resp := teamStatsResponse{Teams: make([]teamStatsPayload, 0)}
+	resp.From, resp.To = formatWindow(window)
+	for _, stats := range s.tenant(r).TeamStats(window) {
+		if teamName != "" && stats.TeamName != teamName {
+			continue
+		}
+		resp.Teams = append(resp.Teams, teamStatsPayload{
+			TeamName:           stats.TeamName,
+			reviewStatsPayload: makeReviewStatsPayload(stats.ReviewStats),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// statsTeam returns the optional team_name filter after checking the team
+// exists. It reports false after writing an error.
+func (s *Server) statsTeam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		return "", true
+	}
+	if _, err := s.tenant(r).GetTeam(teamName); err != nil {
+		if errors.Is(err, store.ErrTeamNotFound) {
+			writeNotFound(w)
+			return "", false
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+		return "", false
+	}
+	return teamName, true
+}
+
+// parseWindow reads the optional RFC 3339 from and to query parameters. It
+// reports false after writing a 400.
+func parseWindow(w http.ResponseWriter, r *http.Request) (store.Window, bool) {
+	var window store.Window
+	q := r.URL.Query()
+	for name, dst := range map[string]*time.Time{"from": &window.From, "to": &window.To} {
+		raw := q.Get(name)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			badRequest(w, name+" must be an RFC 3339 timestamp")
+			return window, false
+		}
+		*dst = t
+	}
+	if !window.From.IsZero() && !window.To.IsZero() && window.From.After(window.To) {
+		badRequest(w, "from must not be after to")
+		return window, false
+	}
+	return window, true
+}
+
+func formatWindow(window store.Window) (from, to *string) {
+	if !window.From.IsZero() {
+		s := window.From.UTC().Format(time.RFC3339)
+		from = &s
+	}
+	if !window.To.IsZero() {
+		s := window.To.UTC().Format(time.RFC3339)
+		to = &s
+	}
+	return from, to
+}
+
+func makeReviewStatsPayload(stats store.ReviewStats) reviewStatsPayload {
+	payload := reviewStatsPayload{
+		Assigned:   stats.Assigned,
+		Open:       stats.Open,
+		Merged:     stats.Merged,
+		Reassigned: stats.Reassigned,
+	}
+	if stats.Merged > 0 {
+		seconds := int64(stats.MedianTimeToMerge / time.Second)
+		payload.MedianTimeToMergeSeconds = &seconds
+	}
+	return payload
+}
+
+func (s *Server) handleFairness(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	window, ok := parseWindow(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := s.tenant(r).FairnessReport(r.URL.Query().Get("team_name"), window)
+	if err != nil {
+		if errors.Is(err, store.ErrTeamNotFound) {
+			writeNotFound(w)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+		return
+	}
+
+	resp := fairnessResponse{Teams: make([]teamFairnessPayload, 0, len(report))}
+	resp.From, resp.To = formatWindow(window)
+	for _, team := range report {
+		payload := teamFairnessPayload{
+			TeamName:    team.TeamName,
+			Assignments: team.Assignments,
+			Gini:        team.Gini,
+			Members:     make([]memberFairnessPayload, 0, len(team.Members)),
+		}
+		for _, m := range team.Members {
+			payload.Members = append(payload.Members, memberFairnessPayload{
+				UserID:      m.UserID,
+				Assignments: m.Assignments,
+				Share:       m.Share,
+				Outlier:     m.Outlier,
+			})
+		}
+		resp.Teams = append(resp.Teams, payload)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleAssignmentHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	window, ok := parseWindow(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := s.tenant(r).AssignmentHistory(r.URL.Query().Get("team_name"), window)
+	if err != nil {
+		if errors.Is(err, store.ErrTeamNotFound) {
+			writeNotFound(w)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+		return
+	}
+
+	resp := assignmentHistoryResponse{Assignments: make([]assignmentRecordPayload, 0, len(history))}
+	resp.From, resp.To = formatWindow(window)
+	for _, a := range history {
+		resp.Assignments = append(resp.Assignments, assignmentRecordPayload{
+			PullRequestID: a.PullRequestID,
+			UserID:        a.UserID,
+			TeamName:      a.TeamName,
+			Kind:          a.Kind,
+			At:            a.At.Format(time.RFC3339),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
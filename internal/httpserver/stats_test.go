@@ -0,0 +1,84 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+func TestReviewStatsResponse(t *testing.T) {
+	now := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	s := New(store.NewOrgs(store.WithSelectionMode(store.SelectionDeterministic), store.WithClock(func() time.Time { return now })))
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+	mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", `{"team_name":"empty","members":[]}`)
+	for _, pr := range []struct {
+		id   string
+		took time.Duration
+	}{{"pr-1", time.Hour}, {"pr-2", 2 * time.Hour}} {
+		createPR(t, s, pr.id)
+		now = now.Add(pr.took)
+		mustDo(t, s, http.StatusOK, http.MethodPost, "/pullRequest/merge", `{"pull_request_id":"`+pr.id+`"}`)
+	}
+
+	for _, path := range []string{"/stats/teams", "/v1/stats/teams"} {
+		w := mustDo(t, s, http.StatusOK, http.MethodGet, path, "")
+		var resp map[string]json.RawMessage
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode %q: %v", w.Body.String(), err)
+		}
+		if _, ok := resp["from"]; ok {
+			t.Errorf("%s: from is set without a window: %s", path, w.Body.String())
+		}
+		want := `[{"team_name":"backend","assigned":4,"open":0,"merged":2,"median_time_to_merge_seconds":5400,"reassigned":0},` +
+			`{"team_name":"empty","assigned":0,"open":0,"merged":0,"median_time_to_merge_seconds":null,"reassigned":0}]`
+		if got := string(resp["teams"]); got != want {
+			t.Errorf("%s: teams = %s\nwant %s", path, got, want)
+		}
+	}
+
+	w := mustDo(t, s, http.StatusOK, http.MethodGet, "/stats/users?team_name=backend&from=2025-01-06T10:30:00Z&to=2025-01-06T12:00:00Z", "")
+	var users struct {
+		From  *string `json:"from"`
+		To    *string `json:"to"`
+		Users []struct {
+			UserID            string `json:"user_id"`
+			TeamName          string `json:"team_name"`
+			Merged            int    `json:"merged"`
+			MedianTimeToMerge *int64 `json:"median_time_to_merge_seconds"`
+		} `json:"users"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &users); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	if users.From == nil || *users.From != "2025-01-06T10:30:00Z" || users.To == nil || *users.To != "2025-01-06T12:00:00Z" {
+		t.Errorf("window = %v..%v, want it echoed", users.From, users.To)
+	}
+	if len(users.Users) != 4 {
+		t.Fatalf("users = %s, want the four backend members", w.Body.String())
+	}
+	merged := 0
+	for _, u := range users.Users {
+		merged += u.Merged
+		if u.TeamName != "backend" {
+			t.Errorf("%s is in %s, want backend", u.UserID, u.TeamName)
+		}
+		// Only pr-2, merged at 12:00 after two hours, is inside the window.
+		if u.Merged == 1 && (u.MedianTimeToMerge == nil || *u.MedianTimeToMerge != 7200) {
+			t.Errorf("%s median = %v, want 7200 seconds", u.UserID, u.MedianTimeToMerge)
+		}
+		if u.Merged == 0 && u.MedianTimeToMerge != nil {
+			t.Errorf("%s median = %d without merges, want null", u.UserID, *u.MedianTimeToMerge)
+		}
+	}
+	if merged != 2 {
+		t.Errorf("reviewers merged %d pull requests in the window, want pr-2 for both its reviewers", merged)
+	}
+
+	for _, query := range []string{"?from=yesterday", "?from=2025-01-07T00:00:00Z&to=2025-01-06T00:00:00Z"} {
+		mustDo(t, s, http.StatusBadRequest, http.MethodGet, "/stats/teams"+query, "")
+	}
+	mustDo(t, s, http.StatusNotFound, http.MethodGet, "/stats/users?team_name=missing", "")
+}
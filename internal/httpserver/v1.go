@@ -0,0 +1,79 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// pathParam copies the value of a /v1 path wildcard into the field a legacy
+// handler reads it from.
+type pathParam struct {
+	wildcard string
+	field    string
+}
+
+func param(wildcard, field string) pathParam {
+	return pathParam{wildcard: wildcard, field: field}
+}
+
+// handle registers h under its legacy path and, when pattern is set, under a
+// method-based /v1 pattern. Either may be empty.
+func (s *Server) handle(legacy, pattern string, h http.HandlerFunc, params ...pathParam) {
+	if legacy != "" {
+		s.mux.HandleFunc(legacy, h)
+		s.routes = append(s.routes, legacy)
+	}
+	if pattern != "" {
+		s.mux.HandleFunc(pattern, v1Alias(params, h))
+		s.routes = append(s.routes, pattern)
+	}
+}
+
+// v1Alias adapts a /v1 request to the legacy handler next. Path values are
+// moved into the query string for GET routes and into the JSON body for all
+// others, whose legacy handlers expect POST.
+func v1Alias(params []pathParam, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			if len(params) > 0 {
+				q := r.URL.Query()
+				for _, p := range params {
+					q.Set(p.field, r.PathValue(p.wildcard))
+				}
+				r.URL.RawQuery = q.Encode()
+			}
+			next(w, r)
+			return
+		}
+
+		body, err := bufferBody(w, r)
+		if err != nil {
+			badRequest(w, "cannot read payload")
+			return
+		}
+
+		if len(params) > 0 {
+			fields := make(map[string]any)
+			if len(bytes.TrimSpace(body)) > 0 {
+				if err := json.Unmarshal(body, &fields); err != nil {
+					badRequest(w, "invalid JSON payload")
+					return
+				}
+			}
+			for _, p := range params {
+				fields[p.field] = r.PathValue(p.wildcard)
+			}
+			if body, err = json.Marshal(fields); err != nil {
+				writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+		}
+
+		r.Method = http.MethodPost
+		next(w, r)
+	}
+}
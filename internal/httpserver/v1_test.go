@@ -0,0 +1,168 @@
+package httpserver
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestV1Alias(t *testing.T) {
+	tests := []struct {
+		name       string
+		pattern    string
+		params     []pathParam
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantQuery  string
+		wantBody   string
+	}{
+		{
+			name:    "GET moves path values into the query",
+			pattern: "GET /v1/teams/{name}", params: []pathParam{param("name", "team_name")},
+			method: http.MethodGet, target: "/v1/teams/backend?verbose=1",
+			wantQuery: "team_name=backend&verbose=1",
+		},
+		{
+			name:    "GET path value wins over the query",
+			pattern: "GET /v1/teams/{name}", params: []pathParam{param("name", "team_name")},
+			method: http.MethodGet, target: "/v1/teams/backend?team_name=frontend",
+			wantQuery: "team_name=backend",
+		},
+		{
+			name:    "GET path values are unescaped",
+			pattern: "GET /v1/teams/{name}", params: []pathParam{param("name", "team_name")},
+			method: http.MethodGet, target: "/v1/teams/a%2Fb%20c",
+			wantQuery: "team_name=a%2Fb+c",
+		},
+		{
+			name:    "GET without params keeps the query",
+			pattern: "GET /v1/stats/users",
+			method:  http.MethodGet, target: "/v1/stats/users?from=x",
+			wantQuery: "from=x",
+		},
+		{
+			name:    "PATCH merges path values into the body",
+			pattern: "PATCH /v1/users/{id}", params: []pathParam{param("id", "user_id")},
+			method: http.MethodPatch, target: "/v1/users/u1", body: `{"is_active":false}`,
+			wantBody: `{"is_active":false,"user_id":"u1"}`,
+		},
+		{
+			name:    "path value wins over the body",
+			pattern: "PATCH /v1/users/{id}", params: []pathParam{param("id", "user_id")},
+			method: http.MethodPatch, target: "/v1/users/u1", body: `{"user_id":"u2","is_active":true}`,
+			wantBody: `{"is_active":true,"user_id":"u1"}`,
+		},
+		{
+			name:    "DELETE without a body",
+			pattern: "DELETE /v1/pull-requests/{id}/reviewers/{user_id}",
+			params:  []pathParam{param("id", "pull_request_id"), param("user_id", "user_id")},
+			method:  http.MethodDelete, target: "/v1/pull-requests/pr-1/reviewers/u2",
+			wantBody: `{"pull_request_id":"pr-1","user_id":"u2"}`,
+		},
+		{
+			name:    "POST without params keeps the body",
+			pattern: "POST /v1/teams",
+			method:  http.MethodPost, target: "/v1/teams", body: `{"team_name":"x"} `,
+			wantBody: `{"team_name":"x"} `,
+		},
+		{
+			name:    "invalid JSON",
+			pattern: "PATCH /v1/users/{id}", params: []pathParam{param("id", "user_id")},
+			method: http.MethodPatch, target: "/v1/users/u1", body: `{`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "body must be an object",
+			pattern: "PATCH /v1/users/{id}", params: []pathParam{param("id", "user_id")},
+			method: http.MethodPatch, target: "/v1/users/u1", body: `[1]`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMethod, gotQuery, gotBody string
+			var gotLength int64
+			mux := http.NewServeMux()
+			mux.HandleFunc(tt.pattern, v1Alias(tt.params, func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				gotMethod, gotQuery, gotBody, gotLength = r.Method, r.URL.RawQuery, string(body), r.ContentLength
+			}))
+
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			if tt.wantStatus != 0 {
+				if w.Code != tt.wantStatus {
+					t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+				}
+				if gotMethod != "" {
+					t.Error("handler ran")
+				}
+				return
+			}
+			wantMethod := http.MethodPost
+			if tt.method == http.MethodGet {
+				wantMethod = http.MethodGet
+			}
+			if gotMethod != wantMethod {
+				t.Errorf("method = %s, want %s", gotMethod, wantMethod)
+			}
+			if gotQuery != tt.wantQuery {
+				t.Errorf("query = %q, want %q", gotQuery, tt.wantQuery)
+			}
+			if gotBody != tt.wantBody {
+				t.Errorf("body = %s, want %s", gotBody, tt.wantBody)
+			}
+			if tt.method != http.MethodGet && gotLength != int64(len(gotBody)) {
+				t.Errorf("ContentLength = %d, body has %d bytes", gotLength, len(gotBody))
+			}
+		})
+	}
+}
+
+// TestV1MatchesLegacy sends the same operations through both route sets.
+func TestV1MatchesLegacy(t *testing.T) {
+	tests := []struct {
+		name         string
+		legacyMethod string
+		legacyPath   string
+		legacyBody   string
+		v1Method     string
+		v1Path       string
+		v1Body       string
+	}{
+		{"get team", http.MethodGet, "/team/get?team_name=backend", "", http.MethodGet, "/v1/teams/backend", ""},
+		{"set activity", http.MethodPost, "/users/setIsActive", `{"user_id":"u4","is_active":false}`,
+			http.MethodPatch, "/v1/users/u4", `{"is_active":false}`},
+		{"set lead", http.MethodPost, "/team/setLead", `{"team_name":"backend","user_id":"u2"}`,
+			http.MethodPut, "/v1/teams/backend/lead", `{"user_id":"u2"}`},
+		{"get pull request", http.MethodGet, "/pullRequest/get?pull_request_id=pr-1", "",
+			http.MethodGet, "/v1/pull-requests/pr-1", ""},
+		{"merge", http.MethodPost, "/pullRequest/merge", `{"pull_request_id":"pr-1"}`,
+			http.MethodPost, "/v1/pull-requests/pr-1/merge", ""},
+		{"missing pull request", http.MethodGet, "/pullRequest/get?pull_request_id=nope", "",
+			http.MethodGet, "/v1/pull-requests/nope", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var responses [2]*httptest.ResponseRecorder
+			for i, req := range [][3]string{{tt.legacyMethod, tt.legacyPath, tt.legacyBody}, {tt.v1Method, tt.v1Path, tt.v1Body}} {
+				s, _ := newTestServer(t)
+				mustDo(t, s, http.StatusCreated, http.MethodPost, "/team/add", teamBackend)
+				createPR(t, s, "pr-1")
+				responses[i] = do(t, s, req[0], req[1], req[2], requestIDHeader, "req-1")
+			}
+			legacy, v1 := responses[0], responses[1]
+			if legacy.Code != v1.Code || legacy.Body.String() != v1.Body.String() || legacy.Header().Get("ETag") != v1.Header().Get("ETag") {
+				t.Errorf("legacy %d %s %s\nv1     %d %s %s",
+					legacy.Code, legacy.Header().Get("ETag"), legacy.Body.String(),
+					v1.Code, v1.Header().Get("ETag"), v1.Body.String())
+			}
+		})
+	}
+}
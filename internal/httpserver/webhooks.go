@@ -0,0 +1,169 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ToxicSozo/GoDraw/internal/webhook"
+)
+
+type webhookAddRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+type webhookPayload struct {
+	WebhookID string   `json:"webhook_id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	CreatedAt string   `json:"created_at"`
+}
+
+type webhookAddResponse struct {
+	Webhook webhookPayload `json:"webhook"`
+}
+
+type webhookListResponse struct {
+	Webhooks []webhookPayload `json:"webhooks"`
+}
+
+type webhookRemoveRequest struct {
+	WebhookID string `json:"webhook_id"`
+}
+
+type webhookDeliveryPayload struct {
+	DeliveryID string `json:"delivery_id"`
+	WebhookID  string `json:"webhook_id"`
+	Event      string `json:"event"`
+	Attempt    int    `json:"attempt"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	At         string `json:"at"`
+}
+
+type webhookDeliveriesResponse struct {
+	Deliveries []webhookDeliveryPayload `json:"deliveries"`
+}
+
+func (s *Server) handleWebhookAdd(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	var req webhookAddRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "invalid JSON payload")
+		return
+	}
+
+	if req.URL == "" || req.Secret == "" {
+		badRequest(w, "url and secret are required")
+		return
+	}
+
+	sub, err := s.webhooks.Subscribe(requestOrg(r), req.URL, req.Events, req.Secret)
+	if err != nil {
+		switch {
+		case errors.Is(err, webhook.ErrInvalidURL):
+			badRequest(w, "url must be an absolute http or https URL")
+		case errors.Is(err, webhook.ErrUnknownEvent):
+			badRequest(w, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, webhookAddResponse{Webhook: makeWebhookPayload(*sub)})
+}
+
+func (s *Server) handleWebhookList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	subs := s.webhooks.Subscriptions(requestOrg(r))
+	resp := webhookListResponse{Webhooks: make([]webhookPayload, 0, len(subs))}
+	for _, sub := range subs {
+		resp.Webhooks = append(resp.Webhooks, makeWebhookPayload(sub))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleWebhookRemove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	var req webhookRemoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "invalid JSON payload")
+		return
+	}
+
+	if req.WebhookID == "" {
+		badRequest(w, "webhook_id is required")
+		return
+	}
+
+	if err := s.webhooks.Unsubscribe(requestOrg(r), req.WebhookID); err != nil {
+		if errors.Is(err, webhook.ErrSubscriptionNotFound) {
+			writeNotFound(w)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	deliveries, err := s.webhooks.Deliveries(requestOrg(r), r.URL.Query().Get("webhook_id"))
+	if err != nil {
+		if errors.Is(err, webhook.ErrSubscriptionNotFound) {
+			writeNotFound(w)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL", err.Error())
+		return
+	}
+
+	resp := webhookDeliveriesResponse{Deliveries: make([]webhookDeliveryPayload, 0, len(deliveries))}
+	for _, d := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, webhookDeliveryPayload{
+			DeliveryID: d.ID,
+			WebhookID:  d.SubscriptionID,
+			Event:      d.Event,
+			Attempt:    d.Attempt,
+			StatusCode: d.StatusCode,
+			Success:    d.Success,
+			Error:      d.Error,
+			At:         d.At.Format(time.RFC3339),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func makeWebhookPayload(sub webhook.Subscription) webhookPayload {
+	return webhookPayload{
+		WebhookID: sub.ID,
+		URL:       sub.URL,
+		Events:    append([]string{}, sub.Events...),
+		CreatedAt: sub.CreatedAt.Format(time.RFC3339),
+	}
+}
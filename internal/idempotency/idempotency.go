@@ -0,0 +1,121 @@
+package idempotency
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInProgress = errors.New("request with this key is in progress")
+	ErrKeyReused  = errors.New("key reused with a different request")
+)
+
+// Response is a recorded reply that is replayed for duplicate requests.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+type Config struct {
+	TTL time.Duration
+	Now func() time.Time
+}
+
+// Store remembers the first response per key until TTL passes.
+type Store struct {
+	cfg Config
+
+	mu        sync.Mutex
+	entries   map[string]*entry
+	nextSweep time.Time
+}
+
+type entry struct {
+	fingerprint string
+	done        bool
+	response    Response
+	expiresAt   time.Time
+}
+
+func New(cfg Config) *Store {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Store{
+		cfg:     cfg,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Begin claims key for a request identified by fingerprint. It returns the
+// recorded response if the key already completed, ErrKeyReused if the key
+// belongs to a different request and ErrInProgress while the first request
+// is still running. A nil response and error mean the caller must run the
+// request and then call Complete or Release.
+func (s *Store) Begin(key, fingerprint string) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.cfg.Now()
+	s.sweepLocked(now)
+
+	if e, ok := s.entries[key]; ok && (!e.done || now.Before(e.expiresAt)) {
+		switch {
+		case e.fingerprint != fingerprint:
+			return nil, ErrKeyReused
+		case !e.done:
+			return nil, ErrInProgress
+		}
+		resp := e.response
+		resp.Header = e.response.Header.Clone()
+		resp.Body = append([]byte(nil), e.response.Body...)
+		return &resp, nil
+	}
+
+	s.entries[key] = &entry{fingerprint: fingerprint}
+	return nil, nil
+}
+
+// Complete records resp for a key claimed by Begin.
+func (s *Store) Complete(key string, resp Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	e.done = true
+	e.response = resp
+	e.expiresAt = s.cfg.Now().Add(s.cfg.TTL)
+}
+
+// Release forgets a key claimed by Begin without recording a response, so
+// the request can be retried.
+func (s *Store) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && !e.done {
+		delete(s.entries, key)
+	}
+}
+
+// sweepLocked drops expired entries at most once per TTL.
+func (s *Store) sweepLocked(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	for key, e := range s.entries {
+		if e.done && !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.nextSweep = now.Add(s.cfg.TTL)
+}
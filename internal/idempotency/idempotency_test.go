@@ -0,0 +1,106 @@
+package idempotency
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+
+func TestStore(t *testing.T) {
+	type step struct {
+		at          time.Duration
+		action      string // begin, complete or release
+		fingerprint string
+		wantErr     error
+		wantReplay  bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"first use runs the request", []step{
+			{action: "begin", fingerprint: "a"},
+		}},
+		{"completed key replays", []step{
+			{action: "begin", fingerprint: "a"},
+			{action: "complete"},
+			{at: time.Hour, action: "begin", fingerprint: "a", wantReplay: true},
+		}},
+		{"in progress", []step{
+			{action: "begin", fingerprint: "a"},
+			{action: "begin", fingerprint: "a", wantErr: ErrInProgress},
+		}},
+		{"reuse with another request while in progress", []step{
+			{action: "begin", fingerprint: "a"},
+			{action: "begin", fingerprint: "b", wantErr: ErrKeyReused},
+		}},
+		{"reuse with another request after completion", []step{
+			{action: "begin", fingerprint: "a"},
+			{action: "complete"},
+			{action: "begin", fingerprint: "b", wantErr: ErrKeyReused},
+		}},
+		{"released key runs again", []step{
+			{action: "begin", fingerprint: "a"},
+			{action: "release"},
+			{action: "begin", fingerprint: "b"},
+		}},
+		{"release keeps a completed response", []step{
+			{action: "begin", fingerprint: "a"},
+			{action: "complete"},
+			{action: "release"},
+			{action: "begin", fingerprint: "a", wantReplay: true},
+		}},
+		{"expired key runs again", []step{
+			{action: "begin", fingerprint: "a"},
+			{action: "complete"},
+			{at: 24 * time.Hour, action: "begin", fingerprint: "b"},
+		}},
+		{"running request does not expire", []step{
+			{action: "begin", fingerprint: "a"},
+			{at: 48 * time.Hour, action: "begin", fingerprint: "a", wantErr: ErrInProgress},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := start
+			s := New(Config{TTL: 24 * time.Hour, Now: func() time.Time { return now }})
+			for i, st := range tt.steps {
+				now = start.Add(st.at)
+				switch st.action {
+				case "complete":
+					s.Complete("k", Response{Status: http.StatusCreated, Header: http.Header{"X": {"1"}}, Body: []byte("body")})
+				case "release":
+					s.Release("k")
+				case "begin":
+					resp, err := s.Begin("k", st.fingerprint)
+					if err != st.wantErr {
+						t.Fatalf("step %d: err = %v, want %v", i, err, st.wantErr)
+					}
+					if (resp != nil) != st.wantReplay {
+						t.Fatalf("step %d: replay = %v, want %v", i, resp, st.wantReplay)
+					}
+					if resp != nil && (resp.Status != http.StatusCreated || string(resp.Body) != "body" || resp.Header.Get("X") != "1") {
+						t.Errorf("step %d: replay = %+v", i, resp)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestReplayIsACopy(t *testing.T) {
+	s := New(Config{})
+	_, _ = s.Begin("k", "a")
+	s.Complete("k", Response{Status: http.StatusOK, Header: http.Header{"X": {"1"}}, Body: []byte("body")})
+
+	first, _ := s.Begin("k", "a")
+	first.Body[0] = 'B'
+	first.Header.Set("X", "2")
+
+	second, _ := s.Begin("k", "a")
+	if string(second.Body) != "body" || second.Header.Get("X") != "1" {
+		t.Errorf("replay was modified through a previous copy: %+v", second)
+	}
+}
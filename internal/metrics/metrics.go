@@ -0,0 +1,270 @@
+// Package metrics keeps counters and histograms and renders them, together
+// with gauges collected on demand, in the Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are latency buckets in seconds, matching the Prometheus client
+// defaults.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type family interface {
+	write(w *bufio.Writer, m matcher)
+}
+
+// matcher selects the series whose label has value. Families without the
+// label, and every series when label is empty, match.
+type matcher struct {
+	label string
+	value string
+}
+
+// Registry renders every metric created through it, in creation order.
+type Registry struct {
+	mu       sync.Mutex
+	families []family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.families = append(r.families, f)
+}
+
+// WriteText writes all metrics in the text exposition format, version 0.0.4.
+func (r *Registry) WriteText(w io.Writer) error {
+	return r.write(w, matcher{})
+}
+
+// WriteTextWhere writes like WriteText but leaves out the series whose label
+// differs from value. Metrics without the label are written in full.
+func (r *Registry) WriteTextWhere(w io.Writer, label, value string) error {
+	return r.write(w, matcher{label: label, value: value})
+}
+
+func (r *Registry) write(w io.Writer, m matcher) error {
+	r.mu.Lock()
+	families := append([]family(nil), r.families...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw, m)
+	}
+	return bw.Flush()
+}
+
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (d desc) header(w *bufio.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, kind)
+}
+
+func (d desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+func (d desc) matches(key string, m matcher) bool {
+	if m.label == "" {
+		return true
+	}
+	for i, label := range d.labels {
+		if label == m.label {
+			return strings.Split(key, "\xff")[i] == m.value
+		}
+	}
+	return true
+}
+
+// series renders a label set; extra is appended as-is, e.g. le="0.1".
+func (d desc) series(name, key, extra string) string {
+	var parts []string
+	if len(d.labels) > 0 {
+		for i, value := range strings.Split(key, "\xff") {
+			parts = append(parts, d.labels[i]+`="`+escapeLabel(value)+`"`)
+		}
+	}
+	if extra != "" {
+		parts = append(parts, extra)
+	}
+	if len(parts) == 0 {
+		return name
+	}
+	return name + "{" + strings.Join(parts, ",") + "}"
+}
+
+// Counter is a monotonically increasing value per label set.
+type Counter struct {
+	desc
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{desc: desc{name: name, help: help, labels: labels}, values: make(map[string]float64)}
+	r.register(c)
+	return c
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	key := c.key(labelValues)
+	c.mu.Lock()
+	c.values[key]++
+	c.mu.Unlock()
+}
+
+func (c *Counter) write(w *bufio.Writer, m matcher) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.header(w, "counter")
+	for _, key := range sortedKeys(c.values) {
+		if !c.matches(key, m) {
+			continue
+		}
+		fmt.Fprintf(w, "%s %s\n", c.series(c.name, key, ""), formatFloat(c.values[key]))
+	}
+}
+
+// Histogram counts observations into cumulative buckets per label set.
+type Histogram struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		desc:    desc{name: name, help: help, labels: labels},
+		buckets: append([]float64(nil), buckets...),
+		values:  make(map[string]*histogramValue),
+	}
+	sort.Float64s(h.buckets)
+	r.register(h)
+	return h
+}
+
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	value, ok := h.values[key]
+	if !ok {
+		value = &histogramValue{counts: make([]uint64, len(h.buckets))}
+		h.values[key] = value
+	}
+	for i, bound := range h.buckets {
+		if v <= bound {
+			value.counts[i]++
+		}
+	}
+	value.sum += v
+	value.count++
+}
+
+func (h *Histogram) write(w *bufio.Writer, m matcher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.header(w, "histogram")
+	for _, key := range sortedKeys(h.values) {
+		if !h.matches(key, m) {
+			continue
+		}
+		value := h.values[key]
+		for i, bound := range h.buckets {
+			le := `le="` + formatFloat(bound) + `"`
+			fmt.Fprintf(w, "%s %d\n", h.series(h.name+"_bucket", key, le), value.counts[i])
+		}
+		fmt.Fprintf(w, "%s %d\n", h.series(h.name+"_bucket", key, `le="+Inf"`), value.count)
+		fmt.Fprintf(w, "%s %s\n", h.series(h.name+"_sum", key, ""), formatFloat(value.sum))
+		fmt.Fprintf(w, "%s %d\n", h.series(h.name+"_count", key, ""), value.count)
+	}
+}
+
+// Gauge reports values computed by collect at scrape time. collect calls set
+// once per label set.
+type Gauge struct {
+	desc
+	collect func(set func(value float64, labelValues ...string))
+}
+
+func (r *Registry) NewGauge(name, help string, collect func(set func(value float64, labelValues ...string)), labels ...string) *Gauge {
+	g := &Gauge{desc: desc{name: name, help: help, labels: labels}, collect: collect}
+	r.register(g)
+	return g
+}
+
+func (g *Gauge) write(w *bufio.Writer, m matcher) {
+	values := make(map[string]float64)
+	g.collect(func(value float64, labelValues ...string) {
+		values[g.key(labelValues)] = value
+	})
+
+	g.header(w, "gauge")
+	for _, key := range sortedKeys(values) {
+		if !g.matches(key, m) {
+			continue
+		}
+		fmt.Fprintf(w, "%s %s\n", g.series(g.name, key, ""), formatFloat(values[key]))
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func escapeHelp(s string) string { return helpEscaper.Replace(s) }
@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	reg := NewRegistry()
+
+	requests := reg.NewCounter("requests_total", "Requests by route.", "route", "status")
+	requests.Inc("/b", "200")
+	requests.Inc("/a", "404")
+	requests.Inc("/b", "200")
+
+	latency := reg.NewHistogram("latency_seconds", "Latency.", []float64{1, 0.1}, "route")
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.5, "/a")
+	latency.Observe(2, "/a")
+
+	reg.NewGauge("open", "Open items\nper team.", func(set func(float64, ...string)) {
+		set(3, `back"end`)
+		set(1.5, `front\end`)
+	}, "team")
+
+	reg.NewCounter("empty_total", "No series yet.")
+
+	var b strings.Builder
+	if err := reg.WriteText(&b); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+
+	want := `# HELP requests_total Requests by route.
+# TYPE requests_total counter
+requests_total{route="/a",status="404"} 1
+requests_total{route="/b",status="200"} 2
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 1
+latency_seconds_bucket{route="/a",le="1"} 2
+latency_seconds_bucket{route="/a",le="+Inf"} 3
+latency_seconds_sum{route="/a"} 2.55
+latency_seconds_count{route="/a"} 3
+# HELP open Open items\nper team.
+# TYPE open gauge
+open{team="back\"end"} 3
+open{team="front\\end"} 1.5
+# HELP empty_total No series yet.
+# TYPE empty_total counter
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCounterWithoutLabels(t *testing.T) {
+	reg := NewRegistry()
+	c := reg.NewCounter("ticks_total", "Ticks.")
+	c.Inc()
+
+	var b strings.Builder
+	_ = reg.WriteText(&b)
+	if !strings.Contains(b.String(), "\nticks_total 1\n") {
+		t.Errorf("output %q lacks an unlabelled series", b.String())
+	}
+}
+
+func TestWriteTextWhere(t *testing.T) {
+	reg := NewRegistry()
+
+	requests := reg.NewCounter("requests_total", "Requests by route.", "route")
+	requests.Inc("/a")
+
+	reassigned := reg.NewCounter("reassigned_total", "Reassignments.", "outcome", "org")
+	reassigned.Inc("success", "acme")
+	reassigned.Inc("success", "globex")
+
+	latency := reg.NewHistogram("latency_seconds", "Latency.", []float64{1}, "org")
+	latency.Observe(0.5, "globex")
+
+	reg.NewGauge("open", "Open items.", func(set func(float64, ...string)) {
+		set(1, "acme", "backend")
+		set(2, "globex", "backend")
+	}, "org", "team")
+
+	var b strings.Builder
+	if err := reg.WriteTextWhere(&b, "org", "acme"); err != nil {
+		t.Fatalf("WriteTextWhere: %v", err)
+	}
+
+	want := `# HELP requests_total Requests by route.
+# TYPE requests_total counter
+requests_total{route="/a"} 1
+# HELP reassigned_total Reassignments.
+# TYPE reassigned_total counter
+reassigned_total{outcome="success",org="acme"} 1
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+# HELP open Open items.
+# TYPE open gauge
+open{org="acme",team="backend"} 1
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteTextWhere() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLabelCountMismatchPanics(t *testing.T) {
+	c := NewRegistry().NewCounter("c_total", "C.", "a", "b")
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Inc with one label value did not panic")
+		}
+	}()
+	c.Inc("only")
+}
+
+func TestFormatFloat(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want string
+	}{
+		{0, "0"},
+		{1, "1"},
+		{0.25, "0.25"},
+		{1e21, "1e+21"},
+		{math.Inf(1), "+Inf"},
+		{math.Inf(-1), "-Inf"},
+		{math.NaN(), "NaN"},
+	}
+	for _, tt := range tests {
+		if got := formatFloat(tt.v); got != tt.want {
+			t.Errorf("formatFloat(%v) = %s, want %s", tt.v, got, tt.want)
+		}
+	}
+}
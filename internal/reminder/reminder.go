@@ -0,0 +1,225 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+const (
+	EventReminder     = "reminder"
+	EventEscalation   = "escalation"
+	EventAutoReassign = "auto_reassign"
+	// EventAutoReassignFailed reports an automatic reassignment the store
+	// rejected; Err holds the reason. It repeats on every scan until the
+	// reviewer acts or a replacement becomes available.
+	EventAutoReassignFailed = "auto_reassign_failed"
+)
+
+type Event struct {
+	Kind          string
+	Org           string
+	PullRequestID string
+	ReviewerID    string
+	TeamName      string
+	LeadID        string
+	ReplacedBy    string
+	Waiting       time.Duration
+	Err           error
+}
+
+type Notifier interface {
+	Notify(Event)
+}
+
+type NotifierFunc func(Event)
+
+func (f NotifierFunc) Notify(e Event) {
+	f(e)
+}
+
+// LogNotifier writes events to the standard logger.
+var LogNotifier = NotifierFunc(func(e Event) {
+	switch e.Kind {
+	case EventEscalation:
+		log.Printf("reminder: %s escalating PR %s review by %s to lead %s after %s", e.Org, e.PullRequestID, e.ReviewerID, e.LeadID, e.Waiting)
+	case EventAutoReassign:
+		log.Printf("reminder: %s reassigned PR %s from %s to %s after %s", e.Org, e.PullRequestID, e.ReviewerID, e.ReplacedBy, e.Waiting)
+	case EventAutoReassignFailed:
+		if !errors.Is(e.Err, store.ErrNoReplacementCandidate) {
+			log.Printf("reminder: %s auto-reassign PR %s from %s: %v", e.Org, e.PullRequestID, e.ReviewerID, e.Err)
+		}
+	default:
+		log.Printf("reminder: %s PR %s is waiting for %s for %s", e.Org, e.PullRequestID, e.ReviewerID, e.Waiting)
+	}
+})
+
+// Config holds the scan interval and the waiting times after which each
+// stage fires. A zero threshold disables that stage.
+type Config struct {
+	Interval      time.Duration
+	RemindAfter   time.Duration
+	EscalateAfter time.Duration
+	ReassignAfter time.Duration
+	Now           func() time.Time
+}
+
+const (
+	stageNone = iota
+	stageReminded
+	stageEscalated
+)
+
+type Scheduler struct {
+	orgs     *store.Orgs
+	notifier Notifier
+	cfg      Config
+	stages   map[string]int
+	// leadless holds the keys whose escalation found no team lead, so that
+	// is logged once rather than on every scan.
+	leadless map[string]struct{}
+}
+
+func New(orgs *store.Orgs, notifier Notifier, cfg Config) *Scheduler {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if notifier == nil {
+		notifier = LogNotifier
+	}
+	return &Scheduler{
+		orgs:     orgs,
+		notifier: notifier,
+		cfg:      cfg,
+		stages:   make(map[string]int),
+		leadless: make(map[string]struct{}),
+	}
+}
+
+// Run scans on every tick until ctx is cancelled. A non-positive interval
+// disables the scheduler.
+func (s *Scheduler) Run(ctx context.Context) {
+	if s.cfg.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Scan()
+		}
+	}
+}
+
+// Scan checks the pending reviews of every organization once. Each stage
+// fires at most once per assignment; a reassigned reviewer starts over, while
+// closing and reopening the pull request does not. Time spent closed does not
+// count as waiting.
+func (s *Scheduler) Scan() {
+	now := s.cfg.Now().UTC()
+	seen := make(map[string]struct{})
+	for _, st := range s.orgs.List() {
+		s.scanOrg(st, now, seen)
+	}
+
+	for key := range s.stages {
+		if _, ok := seen[key]; !ok {
+			delete(s.stages, key)
+		}
+	}
+	for key := range s.leadless {
+		if _, ok := seen[key]; !ok {
+			delete(s.leadless, key)
+		}
+	}
+}
+
+func (s *Scheduler) scanOrg(st *store.Store, now time.Time, seen map[string]struct{}) {
+	for _, p := range st.ListPendingReviews() {
+		key := st.Org() + "/" + p.PullRequestID + "/" + p.UserID + "/" + strconv.FormatInt(p.AssignedAt.UnixNano(), 10)
+		seen[key] = struct{}{}
+		if p.Paused {
+			// Stages reached before the pull request was closed stay
+			// reached after it is reopened.
+			continue
+		}
+
+		waiting := p.Waiting(now)
+		event := Event{
+			Org:           st.Org(),
+			PullRequestID: p.PullRequestID,
+			ReviewerID:    p.UserID,
+			TeamName:      p.TeamName,
+			Waiting:       waiting,
+		}
+
+		if reached(waiting, s.cfg.ReassignAfter) {
+			if s.reassign(st, event) {
+				delete(seen, key)
+				continue
+			}
+		}
+
+		stage := s.stages[key]
+		// Without a lead the stage is not reached; the escalation is sent
+		// once a lead is set, and the reviewer is still reminded meanwhile.
+		if stage < stageEscalated && reached(waiting, s.cfg.EscalateAfter) && s.escalate(st, key, event) {
+			s.stages[key] = stageEscalated
+			continue
+		}
+		if stage < stageReminded && reached(waiting, s.cfg.RemindAfter) {
+			event.Kind = EventReminder
+			s.notifier.Notify(event)
+			s.stages[key] = stageReminded
+		}
+	}
+}
+
+// escalate notifies the lead of the reviewer's team and reports whether there
+// was one.
+func (s *Scheduler) escalate(st *store.Store, key string, event Event) bool {
+	team, err := st.GetTeam(event.TeamName)
+	if err != nil || team.LeadID == "" {
+		if _, logged := s.leadless[key]; !logged {
+			log.Printf("reminder: %s PR %s has no team lead to escalate to", event.Org, event.PullRequestID)
+			s.leadless[key] = struct{}{}
+		}
+		return false
+	}
+	delete(s.leadless, key)
+	event.Kind = EventEscalation
+	event.LeadID = team.LeadID
+	s.notifier.Notify(event)
+	return true
+}
+
+func (s *Scheduler) reassign(st *store.Store, event Event) bool {
+	result, err := st.ReassignReviewer(store.ReassignReviewerInput{
+		PullRequestID: event.PullRequestID,
+		OldReviewerID: event.ReviewerID,
+		Automatic:     true,
+	})
+	if err != nil {
+		event.Kind = EventAutoReassignFailed
+		event.Err = err
+		s.notifier.Notify(event)
+		return false
+	}
+	event.Kind = EventAutoReassign
+	event.ReplacedBy = result.ReplacedBy
+	s.notifier.Notify(event)
+	return true
+}
+
+func reached(waiting, threshold time.Duration) bool {
+	return threshold > 0 && waiting >= threshold
+}
@@ -0,0 +1,346 @@
+package reminder
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+var start = time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+type recorder struct {
+	events []Event
+}
+
+func (r *recorder) Notify(e Event) {
+	r.events = append(r.events, e)
+}
+
+// take returns the events since the last call in a stable "kind:reviewer"
+// form.
+func (r *recorder) take() []string {
+	result := make([]string, 0, len(r.events))
+	for _, e := range r.events {
+		s := e.Kind + ":" + e.ReviewerID
+		switch e.Kind {
+		case EventEscalation:
+			s += "->" + e.LeadID
+		case EventAutoReassign:
+			s += "->" + e.ReplacedBy
+		}
+		result = append(result, s)
+	}
+	r.events = nil
+	sort.Strings(result)
+	return result
+}
+
+// fixture is team backend with author and lead u1, reviewers u2 and u3,
+// and u4, who is inactive until a test activates them. PR pr-1 is opened at
+// start.
+func fixture(t *testing.T, cfg Config) (*Scheduler, *store.Store, *fakeClock, *recorder) {
+	t.Helper()
+	return fixtureWithLead(t, cfg, "u1")
+}
+
+// fixtureWithLead is fixture with lead as the team lead, or none when lead is
+// empty.
+func fixtureWithLead(t *testing.T, cfg Config, lead string) (*Scheduler, *store.Store, *fakeClock, *recorder) {
+	t.Helper()
+
+	clock := &fakeClock{now: start}
+	orgs := store.NewOrgs(store.WithClock(clock.Now), store.WithSelectionMode(store.SelectionDeterministic))
+	st := orgs.Get("acme")
+
+	_, err := st.CreateTeam("backend", []store.TeamMemberInput{
+		{UserID: "u1", Username: "author", IsActive: true},
+		{UserID: "u2", Username: "bob", IsActive: true},
+		{UserID: "u3", Username: "carol", IsActive: true},
+		{UserID: "u4", Username: "dave", IsActive: false},
+	})
+	if err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+	if lead != "" {
+		if _, err := st.SetTeamLead("backend", lead); err != nil {
+			t.Fatalf("SetTeamLead: %v", err)
+		}
+	}
+	pr, err := st.CreatePullRequest(store.CreatePullRequestInput{ID: "pr-1", Name: "Add feature", AuthorID: "u1"})
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	if got := fmt.Sprint(pr.AssignedReviewers); got != "[u2 u3]" && got != "[u3 u2]" {
+		t.Fatalf("reviewers = %s, want u2 and u3", got)
+	}
+
+	cfg.Now = clock.Now
+	rec := &recorder{}
+	return New(orgs, rec, cfg), st, clock, rec
+}
+
+type tick struct {
+	at   time.Duration
+	want []string
+}
+
+func TestSchedulerStages(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		ticks []tick
+	}{
+		{
+			name: "remind once",
+			cfg:  Config{RemindAfter: 24 * time.Hour},
+			ticks: []tick{
+				{at: time.Hour},
+				{at: 24 * time.Hour, want: []string{"reminder:u2", "reminder:u3"}},
+				{at: 25 * time.Hour},
+				{at: 100 * time.Hour},
+			},
+		},
+		{
+			name: "remind then escalate to lead",
+			cfg:  Config{RemindAfter: 24 * time.Hour, EscalateAfter: 48 * time.Hour},
+			ticks: []tick{
+				{at: 24 * time.Hour, want: []string{"reminder:u2", "reminder:u3"}},
+				{at: 47 * time.Hour},
+				{at: 48 * time.Hour, want: []string{"escalation:u2->u1", "escalation:u3->u1"}},
+				{at: 72 * time.Hour},
+			},
+		},
+		{
+			name: "escalation skips a reminder that was never sent",
+			cfg:  Config{RemindAfter: 24 * time.Hour, EscalateAfter: 48 * time.Hour},
+			ticks: []tick{
+				{at: 50 * time.Hour, want: []string{"escalation:u2->u1", "escalation:u3->u1"}},
+				{at: 51 * time.Hour},
+			},
+		},
+		{
+			name: "disabled stages stay quiet",
+			cfg:  Config{},
+			ticks: []tick{
+				{at: 1000 * time.Hour},
+			},
+		},
+		{
+			name: "reassign without candidates falls back to reminding",
+			cfg:  Config{RemindAfter: 24 * time.Hour, ReassignAfter: 24 * time.Hour},
+			ticks: []tick{
+				{at: 24 * time.Hour, want: []string{"auto_reassign_failed:u2", "auto_reassign_failed:u3", "reminder:u2", "reminder:u3"}},
+				{at: 30 * time.Hour, want: []string{"auto_reassign_failed:u2", "auto_reassign_failed:u3"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, clock, rec := fixture(t, tt.cfg)
+			for _, tk := range tt.ticks {
+				clock.Set(start.Add(tk.at))
+				s.Scan()
+				if got, want := fmt.Sprint(rec.take()), fmt.Sprint(orEmpty(tk.want)); got != want {
+					t.Errorf("at +%s: events = %s, want %s", tk.at, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSchedulerReassigns(t *testing.T) {
+	s, st, clock, rec := fixture(t, Config{RemindAfter: 12 * time.Hour, ReassignAfter: 24 * time.Hour})
+
+	clock.Set(start.Add(12 * time.Hour))
+	s.Scan()
+	if got := fmt.Sprint(rec.take()); got != "[reminder:u2 reminder:u3]" {
+		t.Fatalf("at +12h: events = %s", got)
+	}
+
+	if _, err := st.SetUserActive("u4", true); err != nil {
+		t.Fatalf("SetUserActive: %v", err)
+	}
+
+	// Both reviewers are replaced: u2 by u4, the only idle member, and u3
+	// by u2, who is no longer assigned.
+	clock.Set(start.Add(24 * time.Hour))
+	s.Scan()
+	if got := fmt.Sprint(rec.take()); got != "[auto_reassign:u2->u4 auto_reassign:u3->u2]" {
+		t.Fatalf("at +24h: events = %s", got)
+	}
+
+	pr, err := st.GetPullRequest("pr-1")
+	if err != nil {
+		t.Fatalf("GetPullRequest: %v", err)
+	}
+	for _, a := range pr.Assignments {
+		if !a.AssignedAt.Equal(start.Add(24 * time.Hour)) {
+			t.Fatalf("%s assigned at %s, want %s", a.UserID, a.AssignedAt, start.Add(24*time.Hour))
+		}
+	}
+
+	// New assignments start over: no reminder until 12h after them, then
+	// exactly one each.
+	clock.Set(start.Add(35 * time.Hour))
+	s.Scan()
+	if got := rec.take(); len(got) != 0 {
+		t.Errorf("at +35h: events = %v, want none", got)
+	}
+	clock.Set(start.Add(36 * time.Hour))
+	s.Scan()
+	if got := fmt.Sprint(rec.take()); got != "[reminder:u2 reminder:u4]" {
+		t.Errorf("at +36h: events = %s, want [reminder:u2 reminder:u4]", got)
+	}
+	clock.Set(start.Add(40 * time.Hour))
+	s.Scan()
+	if got := rec.take(); len(got) != 0 {
+		t.Errorf("at +40h: events = %v, want none", got)
+	}
+}
+
+func TestSchedulerSkipsFinishedReviews(t *testing.T) {
+	s, st, clock, rec := fixture(t, Config{RemindAfter: 24 * time.Hour})
+
+	clock.Set(start.Add(time.Hour))
+	if _, err := st.SubmitReview("pr-1", "u2"); err != nil {
+		t.Fatalf("SubmitReview: %v", err)
+	}
+
+	clock.Set(start.Add(24 * time.Hour))
+	s.Scan()
+	if got := fmt.Sprint(rec.take()); got != "[reminder:u3]" {
+		t.Errorf("events = %s, want [reminder:u3]", got)
+	}
+
+	if _, err := st.MergePullRequest("pr-1"); err != nil {
+		t.Fatalf("MergePullRequest: %v", err)
+	}
+	clock.Set(start.Add(100 * time.Hour))
+	s.Scan()
+	if got := rec.take(); len(got) != 0 {
+		t.Errorf("after merge: events = %v, want none", got)
+	}
+}
+
+func orEmpty(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// TestSchedulerAcrossCloseAndReopen checks that closing a pull request
+// neither resets the stages already reached nor counts as waiting.
+func TestSchedulerAcrossCloseAndReopen(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		close time.Duration
+		open  time.Duration
+		ticks []tick
+	}{
+		{
+			name:  "escalation is not repeated",
+			cfg:   Config{RemindAfter: 24 * time.Hour, EscalateAfter: 48 * time.Hour},
+			close: 50*time.Hour + time.Minute,
+			open:  51 * time.Hour,
+			ticks: []tick{
+				{at: 50 * time.Hour, want: []string{"escalation:u2->u1", "escalation:u3->u1"}},
+				{at: 50*time.Hour + 30*time.Minute},
+				{at: 52 * time.Hour},
+				{at: 200 * time.Hour},
+			},
+		},
+		{
+			name:  "time spent closed does not count",
+			cfg:   Config{RemindAfter: 24 * time.Hour},
+			close: 10 * time.Hour,
+			open:  40 * time.Hour,
+			ticks: []tick{
+				{at: 30 * time.Hour},
+				{at: 53*time.Hour + 59*time.Minute},
+				{at: 54 * time.Hour, want: []string{"reminder:u2", "reminder:u3"}},
+				{at: 60 * time.Hour},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, st, clock, rec := fixture(t, tt.cfg)
+			closed, opened := false, false
+			for _, tk := range tt.ticks {
+				if !closed && tk.at >= tt.close {
+					clock.Set(start.Add(tt.close))
+					if _, err := st.ClosePullRequest("pr-1"); err != nil {
+						t.Fatalf("ClosePullRequest: %v", err)
+					}
+					closed = true
+				}
+				if !opened && tk.at >= tt.open {
+					clock.Set(start.Add(tt.open))
+					if _, err := st.ReopenPullRequest("pr-1"); err != nil {
+						t.Fatalf("ReopenPullRequest: %v", err)
+					}
+					opened = true
+				}
+				clock.Set(start.Add(tk.at))
+				s.Scan()
+				if got, want := fmt.Sprint(rec.take()), fmt.Sprint(orEmpty(tk.want)); got != want {
+					t.Errorf("at +%s: events = %s, want %s", tk.at, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSchedulerEscalatesOnceLeadIsSet(t *testing.T) {
+	s, st, clock, rec := fixtureWithLead(t, Config{RemindAfter: 24 * time.Hour, EscalateAfter: 48 * time.Hour}, "")
+
+	// Without a lead the reviewers are still reminded, and the escalation
+	// waits instead of being marked sent.
+	for _, tk := range []tick{
+		{at: 50 * time.Hour, want: []string{"reminder:u2", "reminder:u3"}},
+		{at: 60 * time.Hour},
+	} {
+		clock.Set(start.Add(tk.at))
+		s.Scan()
+		if got, want := fmt.Sprint(rec.take()), fmt.Sprint(orEmpty(tk.want)); got != want {
+			t.Errorf("at +%s: events = %s, want %s", tk.at, got, want)
+		}
+	}
+
+	if _, err := st.SetTeamLead("backend", "u4"); err != nil {
+		t.Fatalf("SetTeamLead: %v", err)
+	}
+	for _, tk := range []tick{
+		{at: 61 * time.Hour, want: []string{"escalation:u2->u4", "escalation:u3->u4"}},
+		{at: 100 * time.Hour},
+	} {
+		clock.Set(start.Add(tk.at))
+		s.Scan()
+		if got, want := fmt.Sprint(rec.take()), fmt.Sprint(orEmpty(tk.want)); got != want {
+			t.Errorf("at +%s: events = %s, want %s", tk.at, got, want)
+		}
+	}
+}
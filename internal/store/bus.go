@@ -0,0 +1,177 @@
+package store
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+const defaultSubscriberBuffer = 256
+
+// Event is a domain event emitted after a successful store mutation. Every
+// event carries the organization of the store that emitted it.
+type Event interface {
+	Name() string
+}
+
+type TeamCreated struct {
+	Org  string
+	Team *Team
+	At   time.Time
+}
+
+// TeamLeadChanged is emitted when a team gets a different lead.
+type TeamLeadChanged struct {
+	Org  string
+	Team *Team
+	At   time.Time
+}
+
+// TeamReviewSLAChanged is emitted when a team's review SLA changes.
+type TeamReviewSLAChanged struct {
+	Org  string
+	Team *Team
+	At   time.Time
+}
+
+type UserActivityChanged struct {
+	Org  string
+	User *User
+	At   time.Time
+}
+
+type PRCreated struct {
+	Org      string
+	PR       *PullRequest
+	TeamName string
+	At       time.Time
+}
+
+type ReviewerReassigned struct {
+	Org           string
+	PR            *PullRequest
+	TeamName      string
+	OldReviewerID string
+	NewReviewerID string
+	Automatic     bool
+	At            time.Time
+}
+
+type ReviewerAdded struct {
+	Org        string
+	PR         *PullRequest
+	TeamName   string
+	ReviewerID string
+	At         time.Time
+}
+
+type ReviewerRemoved struct {
+	Org        string
+	PR         *PullRequest
+	TeamName   string
+	ReviewerID string
+	At         time.Time
+}
+
+type ReviewSubmitted struct {
+	Org        string
+	PR         *PullRequest
+	TeamName   string
+	ReviewerID string
+	At         time.Time
+}
+
+type PRMerged struct {
+	Org      string
+	PR       *PullRequest
+	TeamName string
+	At       time.Time
+}
+
+type PRClosed struct {
+	Org      string
+	PR       *PullRequest
+	TeamName string
+	At       time.Time
+}
+
+type PRReopened struct {
+	Org      string
+	PR       *PullRequest
+	TeamName string
+	At       time.Time
+}
+
+func (TeamCreated) Name() string          { return "team.created" }
+func (TeamLeadChanged) Name() string      { return "team.lead_changed" }
+func (TeamReviewSLAChanged) Name() string { return "team.review_sla_changed" }
+func (UserActivityChanged) Name() string  { return "user.activity_changed" }
+func (PRCreated) Name() string            { return "pull_request.created" }
+func (ReviewerReassigned) Name() string   { return "reviewer.reassigned" }
+func (ReviewerAdded) Name() string        { return "reviewer.added" }
+func (ReviewerRemoved) Name() string      { return "reviewer.removed" }
+func (ReviewSubmitted) Name() string      { return "review.submitted" }
+func (PRMerged) Name() string             { return "pull_request.merged" }
+func (PRClosed) Name() string             { return "pull_request.closed" }
+func (PRReopened) Name() string           { return "pull_request.reopened" }
+
+type bus struct {
+	mu   sync.RWMutex
+	subs map[*busSubscriber]struct{}
+}
+
+type busSubscriber struct {
+	ch chan Event
+}
+
+// Subscribe registers handler for every event emitted after this call.
+// Events are queued while the store lock is held, so they arrive in mutation
+// order, but handler runs on its own goroutine and may call back into the
+// store. When handler falls behind by more than the buffer, events are
+// dropped rather than blocking writers. The returned function unsubscribes.
+func (s *Store) Subscribe(handler func(Event)) func() {
+	sub := &busSubscriber{ch: make(chan Event, defaultSubscriberBuffer)}
+
+	s.bus.mu.Lock()
+	s.bus.subs[sub] = struct{}{}
+	s.bus.mu.Unlock()
+
+	go func() {
+		for e := range sub.ch {
+			handler(e)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.bus.mu.Lock()
+			delete(s.bus.subs, sub)
+			close(sub.ch)
+			s.bus.mu.Unlock()
+		})
+	}
+}
+
+func (s *Store) emitLocked(e Event) {
+	if s.buffered {
+		s.pending = append(s.pending, e)
+		return
+	}
+	if s.changed != nil {
+		changed := s.changed
+		s.changed = nil
+		changed(s)
+	}
+
+	s.bus.mu.RLock()
+	defer s.bus.mu.RUnlock()
+
+	for sub := range s.bus.subs {
+		select {
+		case sub.ch <- e:
+		default:
+			log.Printf("store: subscriber buffer full, dropped %s", e.Name())
+		}
+	}
+}
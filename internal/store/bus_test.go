@@ -0,0 +1,190 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSubscribeDeliversInMutationOrder(t *testing.T) {
+	s := New(WithSelectionMode(SelectionDeterministic))
+	next := eventNames(t, s)
+
+	mustCreateTeam(t, s, "backend", "u1", "u2", "u3", "u4")
+	if _, err := s.SetTeamLead("backend", "u2"); err != nil {
+		t.Fatalf("SetTeamLead: %v", err)
+	}
+	if _, err := s.SetTeamReviewSLA("backend", 4*time.Hour); err != nil {
+		t.Fatalf("SetTeamReviewSLA: %v", err)
+	}
+	pr := mustCreatePR(t, s, "pr-1", "u1")
+	if _, err := s.SubmitReview("pr-1", pr.AssignedReviewers[0]); err != nil {
+		t.Fatalf("SubmitReview: %v", err)
+	}
+	if _, err := s.SetUserActive("u3", false); err != nil {
+		t.Fatalf("SetUserActive: %v", err)
+	}
+	if _, err := s.MergePullRequest("pr-1"); err != nil {
+		t.Fatalf("MergePullRequest: %v", err)
+	}
+
+	want := "[team.created team.lead_changed team.review_sla_changed pull_request.created review.submitted user.activity_changed pull_request.merged]"
+	if got := fmt.Sprint(next(7)); got != want {
+		t.Errorf("events = %s, want %s", got, want)
+	}
+}
+
+func TestTeamSettingsEmitOnlyOnChange(t *testing.T) {
+	s := New()
+	mustCreateTeam(t, s, "backend", "u1", "u2")
+	events := make(chan Event, 8)
+	t.Cleanup(s.Subscribe(func(e Event) { events <- e }))
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.SetTeamLead("backend", "u2"); err != nil {
+			t.Fatalf("SetTeamLead: %v", err)
+		}
+		if _, err := s.SetTeamReviewSLA("backend", 8*time.Hour); err != nil {
+			t.Fatalf("SetTeamReviewSLA: %v", err)
+		}
+	}
+	// A marker after the repeated calls proves they emitted nothing.
+	if _, err := s.SetUserActive("u1", false); err != nil {
+		t.Fatalf("SetUserActive: %v", err)
+	}
+
+	var got []Event
+	for len(got) < 3 {
+		select {
+		case e := <-events:
+			got = append(got, e)
+		case <-time.After(time.Second):
+			t.Fatalf("got %d events, want 3", len(got))
+		}
+	}
+	lead, ok := got[0].(TeamLeadChanged)
+	if !ok || lead.Org != DefaultOrg || lead.Team.LeadID != "u2" || lead.Team.Version != 2 {
+		t.Errorf("first event = %#v, want TeamLeadChanged to u2 at version 2", got[0])
+	}
+	sla, ok := got[1].(TeamReviewSLAChanged)
+	if !ok || sla.Team.ReviewSLA != 8*time.Hour || sla.Team.Version != 3 {
+		t.Errorf("second event = %#v, want TeamReviewSLAChanged to 8h at version 3", got[1])
+	}
+	if got[2].Name() != "user.activity_changed" {
+		t.Errorf("third event = %s, want user.activity_changed", got[2].Name())
+	}
+}
+
+func TestSubscribeDropsWhenBufferIsFull(t *testing.T) {
+	s := New()
+	mustCreateTeam(t, s, "backend", "u1", "u2")
+
+	release := make(chan struct{})
+	received := make(chan Event, 2*defaultSubscriberBuffer)
+	t.Cleanup(s.Subscribe(func(e Event) {
+		<-release
+		received <- e
+	}))
+
+	// The handler holds one event and the buffer the next ones; the rest
+	// are dropped without blocking the writer.
+	const emitted = defaultSubscriberBuffer + 50
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < emitted; i++ {
+			if _, err := s.SetUserActive("u1", i%2 == 1); err != nil {
+				t.Errorf("SetUserActive: %v", err)
+				return
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("writes blocked on a slow subscriber")
+	}
+	close(release)
+
+	count := 0
+	for drained := false; !drained; {
+		select {
+		case <-received:
+			count++
+		case <-time.After(100 * time.Millisecond):
+			drained = true
+		}
+	}
+	if count < defaultSubscriberBuffer || count > defaultSubscriberBuffer+1 {
+		t.Errorf("received %d of %d events, want the buffer and the one being handled", count, emitted)
+	}
+
+	// Once the buffer drains, events are delivered again.
+	if _, err := s.SetTeamLead("backend", "u2"); err != nil {
+		t.Fatalf("SetTeamLead: %v", err)
+	}
+	select {
+	case e := <-received:
+		if _, ok := e.(TeamLeadChanged); !ok {
+			t.Errorf("event after draining = %s, want team.lead_changed", e.Name())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no event after the buffer drained")
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	s := New()
+	stayed := eventNames(t, s)
+
+	left := make(chan string, 8)
+	unsubscribe := s.Subscribe(func(e Event) { left <- e.Name() })
+
+	mustCreateTeam(t, s, "backend", "u1", "u2")
+	stayed(1)
+	unsubscribe()
+	unsubscribe()
+
+	if _, err := s.SetTeamLead("backend", "u2"); err != nil {
+		t.Fatalf("SetTeamLead: %v", err)
+	}
+	if got := stayed(1); got[0] != "team.lead_changed" {
+		t.Errorf("remaining subscriber got %v, want team.lead_changed", got)
+	}
+	if got := fmt.Sprint(<-left); got != "team.created" {
+		t.Errorf("unsubscribed handler got %s, want team.created", got)
+	}
+	select {
+	case name := <-left:
+		t.Errorf("unsubscribed handler got %s", name)
+	default:
+	}
+}
+
+func TestTransactionEventsFollowTheOutcome(t *testing.T) {
+	s := New(WithSelectionMode(SelectionDeterministic))
+	mustCreateTeam(t, s, "backend", "u1", "u2", "u3")
+	next := eventNames(t, s)
+
+	_ = s.Transaction(func(tx *Store) error {
+		if _, err := tx.SetTeamLead("backend", "u2"); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	err := s.Transaction(func(tx *Store) error {
+		if _, err := tx.SetTeamReviewSLA("backend", time.Hour); err != nil {
+			return err
+		}
+		_, err := tx.CreatePullRequest(CreatePullRequestInput{ID: "pr-1", Name: "pr-1", AuthorID: "u1"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Transaction: %v", err)
+	}
+
+	want := "[team.review_sla_changed pull_request.created]"
+	if got := fmt.Sprint(next(2)); got != want {
+		t.Errorf("events = %s, want %s", got, want)
+	}
+}
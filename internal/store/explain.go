@@ -0,0 +1,146 @@
+package store
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	AssignmentCreate   = "create"
+	AssignmentReassign = "reassign"
+	AssignmentManual   = "manual"
+)
+
+const (
+	StrategyRandom            = "random"
+	StrategyDeterministic     = "deterministic"
+	StrategyPreferred         = "preferred"
+	StrategyPreferredFallback = "preferred_fallback"
+	StrategyManual            = "manual"
+)
+
+const (
+	ExclusionAuthor          = "author"
+	ExclusionInactive        = "inactive"
+	ExclusionAlreadyAssigned = "already_assigned"
+	ExclusionReplaced        = "replaced"
+	ExclusionAtCapacity      = "at_capacity"
+)
+
+type CandidateExclusion struct {
+	UserID string
+	Reason string
+}
+
+type AssignmentExplanation struct {
+	Kind       string
+	Strategy   string
+	At         time.Time
+	Candidates []string
+	Excluded   []CandidateExclusion
+	Selected   []string
+	Replaced   string
+	// TeamName is the team the selected reviewers belonged to when they were
+	// chosen.
+	TeamName string
+}
+
+func (s *Store) ExplainAssignments(prID string) ([]AssignmentExplanation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.prs[prID]; !ok {
+		return nil, ErrPullRequestNotFound
+	}
+
+	history := s.explanations[prID]
+	result := make([]AssignmentExplanation, 0, len(history))
+	for _, e := range history {
+		result = append(result, cloneExplanation(e))
+	}
+	return result, nil
+}
+
+// recordExplanationLocked keeps the explanation of an assignment. The
+// explanations are the store's only assignment history: statistics and the
+// fairness report are derived from them.
+func (s *Store) recordExplanationLocked(prID string, e AssignmentExplanation) {
+	s.explanations[prID] = append(s.explanations[prID], e)
+}
+
+// evaluateCandidatesLocked splits team members into eligible reviewers and
+// excluded ones with the reason they were skipped. Both lists are ordered by
+// user ID.
+func (s *Store) evaluateCandidatesLocked(team *teamRecord, authorID string, assigned []string, skip string) ([]string, []CandidateExclusion) {
+	assignedSet := make(map[string]struct{}, len(assigned))
+	for _, id := range assigned {
+		if id != skip {
+			assignedSet[id] = struct{}{}
+		}
+	}
+
+	load := s.openReviewsLocked()
+
+	memberIDs := make([]string, 0, len(team.Members))
+	for memberID := range team.Members {
+		memberIDs = append(memberIDs, memberID)
+	}
+	sort.Strings(memberIDs)
+
+	candidates := make([]string, 0, len(memberIDs))
+	excluded := make([]CandidateExclusion, 0)
+	for _, memberID := range memberIDs {
+		reason := ""
+		switch {
+		case skip != "" && memberID == skip:
+			reason = ExclusionReplaced
+		case memberID == authorID:
+			reason = ExclusionAuthor
+		default:
+			if _, exists := assignedSet[memberID]; exists {
+				reason = ExclusionAlreadyAssigned
+			} else if user := s.users[memberID]; user == nil || !user.IsActive {
+				reason = ExclusionInactive
+			} else if s.atCapacityLocked(load, memberID) {
+				reason = ExclusionAtCapacity
+			}
+		}
+
+		if reason != "" {
+			excluded = append(excluded, CandidateExclusion{UserID: memberID, Reason: reason})
+			continue
+		}
+		candidates = append(candidates, memberID)
+	}
+	return candidates, excluded
+}
+
+// openReviewsLocked counts the open pull requests each user is assigned to.
+// It returns nil when reviewers have no limit.
+func (s *Store) openReviewsLocked() map[string]int {
+	if s.maxOpenReviews <= 0 {
+		return nil
+	}
+	load := make(map[string]int)
+	for _, pr := range s.prs {
+		if pr.Status != StatusOpen {
+			continue
+		}
+		for _, id := range pr.AssignedReviewers {
+			load[id]++
+		}
+	}
+	return load
+}
+
+func (s *Store) atCapacityLocked(load map[string]int, userID string) bool {
+	return s.maxOpenReviews > 0 && load[userID] >= s.maxOpenReviews
+}
+
+func cloneExplanation(e AssignmentExplanation) AssignmentExplanation {
+	clone := e
+	clone.Candidates = append([]string(nil), e.Candidates...)
+	clone.Excluded = append([]CandidateExclusion(nil), e.Excluded...)
+	clone.Selected = append([]string(nil), e.Selected...)
+	return clone
+}
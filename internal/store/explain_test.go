@@ -0,0 +1,142 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// describe renders an explanation compactly for comparison.
+func describe(e AssignmentExplanation) string {
+	excluded := make([]string, 0, len(e.Excluded))
+	for _, x := range e.Excluded {
+		excluded = append(excluded, x.UserID+":"+x.Reason)
+	}
+	return fmt.Sprintf("%s/%s team=%s candidates=%v excluded=[%s] selected=%v replaced=%s",
+		e.Kind, e.Strategy, e.TeamName, e.Candidates, strings.Join(excluded, " "), e.Selected, e.Replaced)
+}
+
+func TestExplainAssignments(t *testing.T) {
+	s := New(WithSelectionMode(SelectionDeterministic))
+	mustCreateTeam(t, s, "backend", "u1", "u2", "u3", "u4", "u5")
+	mustCreateTeam(t, s, "frontend", "f1", "f2")
+	if _, err := s.SetUserActive("u5", false); err != nil {
+		t.Fatalf("SetUserActive: %v", err)
+	}
+
+	pr := mustCreatePR(t, s, "pr-1", "u1")
+	first, second := pr.AssignedReviewers[0], pr.AssignedReviewers[1]
+	result, err := s.ReassignReviewer(ReassignReviewerInput{PullRequestID: "pr-1", OldReviewerID: first})
+	if err != nil {
+		t.Fatalf("ReassignReviewer: %v", err)
+	}
+	if _, err := s.AddReviewer("pr-1", "f1"); err != nil {
+		t.Fatalf("AddReviewer: %v", err)
+	}
+
+	history, err := s.ExplainAssignments("pr-1")
+	if err != nil {
+		t.Fatalf("ExplainAssignments: %v", err)
+	}
+	var idle []string
+	for _, id := range []string{"u2", "u3", "u4"} {
+		if id != first && id != second {
+			idle = append(idle, id)
+		}
+	}
+	want := []string{
+		fmt.Sprintf("create/deterministic team=backend candidates=[u2 u3 u4] excluded=[u1:author u5:inactive] selected=%v replaced=", pr.AssignedReviewers),
+		describe(AssignmentExplanation{
+			Kind:       AssignmentReassign,
+			Strategy:   StrategyDeterministic,
+			TeamName:   "backend",
+			Candidates: idle,
+			Excluded:   sortedExclusions(map[string]string{"u1": ExclusionAuthor, first: ExclusionReplaced, second: ExclusionAlreadyAssigned, "u5": ExclusionInactive}),
+			Selected:   []string{result.ReplacedBy},
+			Replaced:   first,
+		}),
+		"manual/manual team=frontend candidates=[f1 f2] excluded=[] selected=[f1] replaced=",
+	}
+	if len(history) != len(want) {
+		t.Fatalf("got %d explanations, want %d", len(history), len(want))
+	}
+	for i := range want {
+		if got := describe(history[i]); got != want[i] {
+			t.Errorf("explanation %d = %s\nwant %s", i, got, want[i])
+		}
+	}
+
+	history[0].Candidates[0] = "changed"
+	if again, _ := s.ExplainAssignments("pr-1"); again[0].Candidates[0] == "changed" {
+		t.Errorf("ExplainAssignments returned the stored slices")
+	}
+	if _, err := s.ExplainAssignments("missing"); !errors.Is(err, ErrPullRequestNotFound) {
+		t.Errorf("ExplainAssignments(missing) = %v, want ErrPullRequestNotFound", err)
+	}
+}
+
+// sortedExclusions lists reasons by user ID, as evaluateCandidatesLocked does.
+func sortedExclusions(reasons map[string]string) []CandidateExclusion {
+	ids := make([]string, 0, len(reasons))
+	for id := range reasons {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	out := make([]CandidateExclusion, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, CandidateExclusion{UserID: id, Reason: reasons[id]})
+	}
+	return out
+}
+
+func TestMaxOpenReviews(t *testing.T) {
+	s := New(WithSelectionMode(SelectionDeterministic), WithMaxOpenReviews(1))
+	mustCreateTeam(t, s, "backend", "u1", "u2", "u3", "u4", "u5")
+
+	busy := mustCreatePR(t, s, "pr-1", "u1").AssignedReviewers
+	second := mustCreatePR(t, s, "pr-2", "u1")
+
+	history, _ := s.ExplainAssignments("pr-2")
+	atCapacity := 0
+	for _, x := range history[0].Excluded {
+		if x.Reason != ExclusionAtCapacity {
+			continue
+		}
+		atCapacity++
+		if reviewerIndex(busy, x.UserID) == -1 {
+			t.Errorf("%s is at capacity without an open review", x.UserID)
+		}
+	}
+	if atCapacity != len(busy) {
+		t.Errorf("%d members excluded at capacity, want %d", atCapacity, len(busy))
+	}
+	for _, id := range busy {
+		if reviewerIndex(second.AssignedReviewers, id) != -1 {
+			t.Errorf("pr-2 was assigned %s, who already reviews pr-1", id)
+		}
+		if reviewerIndex(history[0].Candidates, id) != -1 {
+			t.Errorf("%s is a candidate while at capacity", id)
+		}
+	}
+	if len(second.AssignedReviewers) != 2 {
+		t.Fatalf("pr-2 reviewers = %v, want the two free members", second.AssignedReviewers)
+	}
+
+	// Everyone but the author reviews something now.
+	if _, err := s.AddReviewer("pr-2", busy[0]); !errors.Is(err, ErrReviewerAtCapacity) {
+		t.Errorf("AddReviewer at capacity = %v, want ErrReviewerAtCapacity", err)
+	}
+	if _, err := s.ReassignReviewer(ReassignReviewerInput{PullRequestID: "pr-2", OldReviewerID: second.AssignedReviewers[0]}); !errors.Is(err, ErrNoReplacementCandidate) {
+		t.Errorf("ReassignReviewer with everyone at capacity = %v, want ErrNoReplacementCandidate", err)
+	}
+
+	// Merged pull requests no longer count.
+	if _, err := s.MergePullRequest("pr-1"); err != nil {
+		t.Fatalf("MergePullRequest: %v", err)
+	}
+	if _, err := s.AddReviewer("pr-2", busy[0]); err != nil {
+		t.Errorf("AddReviewer after the review was merged: %v", err)
+	}
+}
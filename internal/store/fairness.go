@@ -0,0 +1,191 @@
+package store
+
+import (
+	"sort"
+	"time"
+)
+
+// Members whose assignment count is this far above or below the team mean
+// are reported as outliers.
+const (
+	OverloadFactor  = 1.5
+	UnderloadFactor = 0.5
+)
+
+const (
+	OutlierOverloaded  = "overloaded"
+	OutlierUnderloaded = "underloaded"
+)
+
+// AssignmentRecord is one reviewer picked by an assignment explanation. Every
+// kind counts: AssignmentCreate, AssignmentReassign and AssignmentManual.
+type AssignmentRecord struct {
+	PullRequestID string
+	UserID        string
+	// TeamName is the reviewer's team at the time of the assignment.
+	TeamName string
+	Kind     string
+	At       time.Time
+}
+
+type MemberFairness struct {
+	UserID      string
+	Assignments int
+	// Share is the member's fraction of the team's assignments.
+	Share float64
+	// Outlier is OutlierOverloaded, OutlierUnderloaded or empty.
+	Outlier string
+}
+
+type TeamFairness struct {
+	TeamName    string
+	Assignments int
+	// Gini is 0 when every member got the same number of assignments and
+	// approaches 1 when one member got all of them.
+	Gini    float64
+	Members []MemberFairness
+}
+
+// AssignmentHistory returns the assignments made in window in the order they
+// happened. An empty teamName covers all teams.
+func (s *Store) AssignmentHistory(teamName string, window Window) ([]AssignmentRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if teamName != "" {
+		if _, ok := s.teams[teamName]; !ok {
+			return nil, ErrTeamNotFound
+		}
+	}
+
+	result := make([]AssignmentRecord, 0)
+	for _, a := range s.assignmentsLocked(window) {
+		if teamName == "" || a.TeamName == teamName {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+// assignmentsLocked flattens the explanations in window into one record per
+// selected reviewer, ordered by time, then pull request.
+func (s *Store) assignmentsLocked(window Window) []AssignmentRecord {
+	ids := make([]string, 0, len(s.explanations))
+	for id := range s.explanations {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var result []AssignmentRecord
+	for _, id := range ids {
+		for _, e := range s.explanations[id] {
+			if !window.contains(e.At) {
+				continue
+			}
+			for _, userID := range e.Selected {
+				result = append(result, AssignmentRecord{
+					PullRequestID: id,
+					UserID:        userID,
+					TeamName:      e.TeamName,
+					Kind:          e.Kind,
+					At:            e.At,
+				})
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].At.Before(result[j].At)
+	})
+	return result
+}
+
+// FairnessReport measures how evenly assignments in window were spread over
+// each team's members, ordered by team name. It counts the same assignments
+// as UserStats, manual additions included, towards the reviewer's team at the
+// time they were made. Current members are included with zero assignments
+// unless they are inactive. An empty teamName covers all teams.
+func (s *Store) FairnessReport(teamName string, window Window) ([]TeamFairness, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if teamName != "" {
+		if _, ok := s.teams[teamName]; !ok {
+			return nil, ErrTeamNotFound
+		}
+	}
+
+	counts := make(map[string]map[string]int)
+	for name, team := range s.teams {
+		if teamName != "" && name != teamName {
+			continue
+		}
+		members := make(map[string]int, len(team.Members))
+		for id := range team.Members {
+			if user := s.users[id]; user != nil && user.IsActive {
+				members[id] = 0
+			}
+		}
+		counts[name] = members
+	}
+	for _, a := range s.assignmentsLocked(window) {
+		if members, ok := counts[a.TeamName]; ok {
+			members[a.UserID]++
+		}
+	}
+
+	result := make([]TeamFairness, 0, len(counts))
+	for name, members := range counts {
+		result = append(result, teamFairness(name, members))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].TeamName < result[j].TeamName
+	})
+	return result, nil
+}
+
+func teamFairness(name string, counts map[string]int) TeamFairness {
+	report := TeamFairness{TeamName: name, Members: make([]MemberFairness, 0, len(counts))}
+	values := make([]float64, 0, len(counts))
+	for id, n := range counts {
+		report.Assignments += n
+		report.Members = append(report.Members, MemberFairness{UserID: id, Assignments: n})
+		values = append(values, float64(n))
+	}
+	sort.Slice(report.Members, func(i, j int) bool {
+		return report.Members[i].UserID < report.Members[j].UserID
+	})
+	if report.Assignments == 0 {
+		return report
+	}
+
+	mean := float64(report.Assignments) / float64(len(counts))
+	for i := range report.Members {
+		m := &report.Members[i]
+		m.Share = float64(m.Assignments) / float64(report.Assignments)
+		switch n := float64(m.Assignments); {
+		case n > mean*OverloadFactor:
+			m.Outlier = OutlierOverloaded
+		case n < mean*UnderloadFactor:
+			m.Outlier = OutlierUnderloaded
+		}
+	}
+	report.Gini = gini(values)
+	return report
+}
+
+// gini computes the Gini coefficient of non-negative values.
+func gini(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	n := float64(len(sorted))
+	var sum, weighted float64
+	for i, v := range sorted {
+		sum += v
+		weighted += float64(i+1) * v
+	}
+	if sum == 0 {
+		return 0
+	}
+	return (2*weighted)/(n*sum) - (n+1)/n
+}
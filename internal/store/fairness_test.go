@@ -0,0 +1,187 @@
+package store
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGini(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   float64
+	}{
+		{"empty", nil, 0},
+		{"all zero", []float64{0, 0, 0}, 0},
+		{"equal", []float64{2, 2, 2, 2}, 0},
+		{"one takes all", []float64{0, 0, 0, 4}, 0.75},
+		{"uneven", []float64{3, 0, 1, 0}, 0.625},
+		{"two members", []float64{4, 2}, 1.0 / 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gini(tt.values); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("gini(%v) = %v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTeamFairnessOutliers(t *testing.T) {
+	tests := []struct {
+		name    string
+		counts  map[string]int
+		want    map[string]string
+		wantSum int
+	}{
+		{
+			name:    "mean 3",
+			counts:  map[string]int{"a": 6, "b": 2, "c": 1, "d": 3},
+			want:    map[string]string{"a": OutlierOverloaded, "b": "", "c": OutlierUnderloaded, "d": ""},
+			wantSum: 12,
+		},
+		{
+			name:    "bounds are exclusive",
+			counts:  map[string]int{"a": 3, "b": 1, "c": 2},
+			want:    map[string]string{"a": "", "b": "", "c": ""},
+			wantSum: 6,
+		},
+		{
+			name:    "nothing assigned",
+			counts:  map[string]int{"a": 0, "b": 0},
+			want:    map[string]string{"a": "", "b": ""},
+			wantSum: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := teamFairness("backend", tt.counts)
+			if report.Assignments != tt.wantSum {
+				t.Errorf("Assignments = %d, want %d", report.Assignments, tt.wantSum)
+			}
+			var share float64
+			for i, m := range report.Members {
+				if i > 0 && report.Members[i-1].UserID >= m.UserID {
+					t.Errorf("members not ordered by ID: %v", report.Members)
+				}
+				if m.Outlier != tt.want[m.UserID] {
+					t.Errorf("%s outlier = %q, want %q", m.UserID, m.Outlier, tt.want[m.UserID])
+				}
+				share += m.Share
+			}
+			if tt.wantSum > 0 && math.Abs(share-1) > 1e-9 {
+				t.Errorf("shares sum to %v, want 1", share)
+			}
+		})
+	}
+}
+
+// TestFairnessMatchesUserStats checks that the fairness report and the user
+// statistics count the same assignments, manual ones included.
+func TestFairnessMatchesUserStats(t *testing.T) {
+	clock := newTestClock()
+	s := New(WithClock(clock.Now), WithSelectionMode(SelectionDeterministic))
+	mustCreateTeam(t, s, "backend", "u1", "u2", "u3", "u4")
+
+	mustCreatePR(t, s, "pr-1", "u1")
+	pr := mustCreatePR(t, s, "pr-2", "u2")
+	idle := ""
+	for _, id := range []string{"u1", "u3", "u4"} {
+		if reviewerIndex(pr.AssignedReviewers, id) == -1 {
+			idle = id
+		}
+	}
+	clock.Advance(time.Hour)
+	if _, err := s.AddReviewer("pr-2", idle); err != nil {
+		t.Fatalf("AddReviewer: %v", err)
+	}
+	clock.Advance(time.Hour)
+	if _, err := s.ReassignReviewer(ReassignReviewerInput{PullRequestID: "pr-1", OldReviewerID: firstReviewer(t, s, "pr-1")}); err != nil {
+		t.Fatalf("ReassignReviewer: %v", err)
+	}
+
+	history, err := s.AssignmentHistory("", Window{})
+	if err != nil {
+		t.Fatalf("AssignmentHistory: %v", err)
+	}
+	kinds := map[string]int{}
+	for i, a := range history {
+		kinds[a.Kind]++
+		if i > 0 && a.At.Before(history[i-1].At) {
+			t.Errorf("history out of order at %d", i)
+		}
+		if a.TeamName != "backend" {
+			t.Errorf("record %d team = %q, want backend", i, a.TeamName)
+		}
+	}
+	if kinds[AssignmentCreate] != 4 || kinds[AssignmentManual] != 1 || kinds[AssignmentReassign] != 1 {
+		t.Errorf("kinds = %v, want 4 create, 1 manual, 1 reassign", kinds)
+	}
+
+	report, err := s.FairnessReport("backend", Window{})
+	if err != nil {
+		t.Fatalf("FairnessReport: %v", err)
+	}
+	fair := map[string]int{}
+	for _, m := range report[0].Members {
+		fair[m.UserID] = m.Assignments
+	}
+	for _, stats := range s.UserStats(Window{}) {
+		if fair[stats.UserID] != stats.Assigned {
+			t.Errorf("%s: fairness counts %d, stats count %d", stats.UserID, fair[stats.UserID], stats.Assigned)
+		}
+	}
+	if report[0].Assignments != len(history) {
+		t.Errorf("team assignments = %d, want %d", report[0].Assignments, len(history))
+	}
+}
+
+func TestFairnessWindowAndTeamMoves(t *testing.T) {
+	clock := newTestClock()
+	s := New(WithClock(clock.Now), WithSelectionMode(SelectionDeterministic))
+	mustCreateTeam(t, s, "backend", "u1", "u2", "u3")
+
+	mustCreatePR(t, s, "pr-1", "u1")
+	clock.Advance(24 * time.Hour)
+	mustCreateTeam(t, s, "frontend", "u2", "u5")
+	mustCreatePR(t, s, "pr-2", "u5")
+
+	tests := []struct {
+		name   string
+		team   string
+		window Window
+		want   map[string]int
+	}{
+		{"backend keeps u2's old assignment", "backend", Window{}, map[string]int{"u1": 0, "u2": 1, "u3": 1}},
+		{"frontend counts only the move onwards", "frontend", Window{}, map[string]int{"u2": 1, "u5": 0}},
+		{"window excludes the first day", "backend", Window{From: testStart.Add(time.Hour)}, map[string]int{"u1": 0, "u3": 0}},
+		{"window ends before the move", "frontend", Window{To: testStart.Add(time.Hour)}, map[string]int{"u2": 0, "u5": 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := s.FairnessReport(tt.team, tt.window)
+			if err != nil {
+				t.Fatalf("FairnessReport: %v", err)
+			}
+			got := map[string]int{}
+			for _, m := range report[0].Members {
+				got[m.UserID] = m.Assignments
+			}
+			for id, n := range tt.want {
+				if got[id] != n {
+					t.Errorf("%s = %d, want %d (report %v)", id, got[id], n, got)
+				}
+			}
+			for id := range got {
+				if _, ok := tt.want[id]; !ok && got[id] > 0 {
+					t.Errorf("unexpected member %s with %d", id, got[id])
+				}
+			}
+		})
+	}
+
+	if _, err := s.FairnessReport("missing", Window{}); err != ErrTeamNotFound {
+		t.Errorf("unknown team: err = %v, want %v", err, ErrTeamNotFound)
+	}
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"time"
+)
+
+const (
+	SelectionRandom        = "random"
+	SelectionDeterministic = "deterministic"
+)
+
+type Option func(*Store)
+
+// WithClock replaces time.Now as the source of timestamps.
+func WithClock(now func() time.Time) Option {
+	return func(s *Store) {
+		s.now = now
+	}
+}
+
+// WithSeed makes random reviewer selection reproducible.
+func WithSeed(seed int64) Option {
+	return func(s *Store) {
+		s.seed = seed
+	}
+}
+
+// WithSelectionMode switches between random selection and a deterministic
+// mode where reviewers are derived from a hash of the pull request ID, so the
+// same team state always yields the same reviewers.
+func WithSelectionMode(mode string) Option {
+	return func(s *Store) {
+		s.selection = mode
+	}
+}
+
+// WithDefaultReviewSLA sets the first-response deadline for teams that have
+// not configured their own.
+func WithDefaultReviewSLA(d time.Duration) Option {
+	return func(s *Store) {
+		s.reviewSLA = d
+	}
+}
+
+// WithMaxOpenReviews limits how many open pull requests a reviewer is
+// assigned to at once. Automatic selection skips reviewers at the limit and
+// AddReviewer rejects them with ErrReviewerAtCapacity. Zero means no limit.
+func WithMaxOpenReviews(n int) Option {
+	return func(s *Store) {
+		s.maxOpenReviews = n
+	}
+}
+
+func (s *Store) nowUTC() time.Time {
+	return s.now().UTC()
+}
+
+func (s *Store) autoStrategy() string {
+	if s.selection == SelectionDeterministic {
+		return StrategyDeterministic
+	}
+	return StrategyRandom
+}
+
+// prRand is the generator for the reviewers of a new pull request. It
+// depends only on the seed and the pull request ID, so a preview draws the
+// same reviewers as the creation that follows it while the team is unchanged.
+func (s *Store) prRand(prID string) *rand.Rand {
+	return rand.New(rand.NewSource(s.seed ^ int64(hashKey(prID))))
+}
+
+// selectLocked picks up to limit reviewers from candidates, which must be
+// sorted. key identifies the decision for deterministic mode.
+func (s *Store) selectLocked(rnd *rand.Rand, candidates []string, limit int, key string) []string {
+	if limit > len(candidates) {
+		limit = len(candidates)
+	}
+	if limit == 0 {
+		return nil
+	}
+
+	selected := make([]string, 0, limit)
+	if s.selection == SelectionDeterministic {
+		start := int(hashKey(key) % uint32(len(candidates)))
+		for i := 0; i < limit; i++ {
+			selected = append(selected, candidates[(start+i)%len(candidates)])
+		}
+		return selected
+	}
+
+	shuffled := append([]string(nil), candidates...)
+	rnd.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return append(selected, shuffled[:limit]...)
+}
+
+func hashKey(key string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return h.Sum32()
+}
@@ -0,0 +1,108 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSelectionIsReproducible(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []Option
+		want       map[string]string
+		wantReplan string
+	}{
+		{
+			name:       "random, seed 42",
+			opts:       []Option{WithSeed(42)},
+			want:       map[string]string{"pr-1": "[u2 u4]", "pr-2": "[u5 u3]", "pr-3": "[u2 u5]"},
+			wantReplan: "u5",
+		},
+		{
+			name:       "random, seed 7",
+			opts:       []Option{WithSeed(7)},
+			want:       map[string]string{"pr-1": "[u5 u3]", "pr-2": "[u2 u4]", "pr-3": "[u3 u4]"},
+			wantReplan: "u2",
+		},
+		{
+			name:       "deterministic ignores the seed",
+			opts:       []Option{WithSeed(42), WithSelectionMode(SelectionDeterministic)},
+			want:       map[string]string{"pr-1": "[u3 u4]", "pr-2": "[u2 u3]", "pr-3": "[u5 u2]"},
+			wantReplan: "u2",
+		},
+		{
+			name:       "deterministic without a seed",
+			opts:       []Option{WithSelectionMode(SelectionDeterministic)},
+			want:       map[string]string{"pr-1": "[u3 u4]", "pr-2": "[u2 u3]", "pr-3": "[u5 u2]"},
+			wantReplan: "u2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newTestClock()
+			s := New(append(tt.opts, WithClock(clock.Now))...)
+			mustCreateTeam(t, s, "backend", "u1", "u2", "u3", "u4", "u5")
+
+			for _, id := range []string{"pr-1", "pr-2", "pr-3"} {
+				pr := mustCreatePR(t, s, id, "u1")
+				if got := fmt.Sprint(pr.AssignedReviewers); got != tt.want[id] {
+					t.Errorf("%s reviewers = %s, want %s", id, got, tt.want[id])
+				}
+				if !pr.CreatedAt.Equal(testStart) {
+					t.Errorf("%s created at %s, want %s", id, pr.CreatedAt, testStart)
+				}
+			}
+
+			result, err := s.ReassignReviewer(ReassignReviewerInput{PullRequestID: "pr-1", OldReviewerID: firstReviewer(t, s, "pr-1")})
+			if err != nil {
+				t.Fatalf("ReassignReviewer: %v", err)
+			}
+			if result.ReplacedBy != tt.wantReplan {
+				t.Errorf("replaced by %s, want %s", result.ReplacedBy, tt.wantReplan)
+			}
+		})
+	}
+}
+
+// TestPreviewMatchesCreate checks that a preview proposes the reviewers the
+// following creation assigns, whatever was created in between, and that it
+// changes nothing.
+func TestPreviewMatchesCreate(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"random", []Option{WithSeed(42)}},
+		{"random with another seed", []Option{WithSeed(-3)}},
+		{"deterministic", []Option{WithSelectionMode(SelectionDeterministic)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(tt.opts...)
+			mustCreateTeam(t, s, "backend", "u1", "u2", "u3", "u4", "u5")
+
+			for i := 1; i <= 10; i++ {
+				input := CreatePullRequestInput{ID: fmt.Sprintf("pr-%d", i), Name: "change", AuthorID: "u1"}
+				preview, err := s.PreviewPullRequest(input)
+				if err != nil {
+					t.Fatalf("PreviewPullRequest(%s): %v", input.ID, err)
+				}
+				if _, err := s.GetPullRequest(input.ID); err != ErrPullRequestNotFound {
+					t.Fatalf("preview created %s: err = %v", input.ID, err)
+				}
+
+				pr, err := s.CreatePullRequest(input)
+				if err != nil {
+					t.Fatalf("CreatePullRequest(%s): %v", input.ID, err)
+				}
+				if got, want := fmt.Sprint(pr.AssignedReviewers), fmt.Sprint(preview.Reviewers); got != want {
+					t.Errorf("%s: created with %s, previewed %s", input.ID, got, want)
+				}
+			}
+
+			if _, err := s.PreviewPullRequest(CreatePullRequestInput{ID: "pr-1", AuthorID: "u1"}); err != ErrPullRequestExists {
+				t.Errorf("preview of an existing PR: err = %v, want %v", err, ErrPullRequestExists)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DefaultOrg is the organization of a standalone store and of requests that
+// do not name one.
+const DefaultOrg = "default"
+
+// Orgs keeps one isolated Store per organization. Teams, users and pull
+// requests of different organizations never share a key space, so names may
+// collide freely. DefaultOrg always exists; other organizations are created
+// explicitly with Get, or by the first change committed to a store obtained
+// with Acquire.
+type Orgs struct {
+	opts []Option
+
+	mu       sync.Mutex
+	stores   map[string]*Store
+	pending  map[string]*pendingOrg
+	handlers map[*orgHandler]struct{}
+	// probe is closed when the running readiness probe finishes; nil when
+	// none is running.
+	probe chan struct{}
+}
+
+type orgHandler struct {
+	handle func(Event)
+	unsubs []func()
+}
+
+// pendingOrg is the store of an organization that does not exist yet, shared
+// by the requests that acquired it.
+type pendingOrg struct {
+	store *Store
+	refs  int
+}
+
+func NewOrgs(opts ...Option) *Orgs {
+	o := &Orgs{
+		opts:     opts,
+		stores:   make(map[string]*Store),
+		pending:  make(map[string]*pendingOrg),
+		handlers: make(map[*orgHandler]struct{}),
+	}
+	o.Get(DefaultOrg)
+	return o
+}
+
+// Lookup returns the store of org if it exists.
+func (o *Orgs) Lookup(org string) (*Store, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	st, ok := o.stores[org]
+	return st, ok
+}
+
+// Get returns the store of org, creating an empty one on first use.
+func (o *Orgs) Get(org string) *Store {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if st, ok := o.stores[org]; ok {
+		return st
+	}
+	if p, ok := o.pending[org]; ok {
+		o.registerLocked(p.store)
+		return p.store
+	}
+
+	st := New(o.opts...)
+	st.org = org
+	o.registerLocked(st)
+	return st
+}
+
+// Acquire returns the store of org for the duration of one request and a
+// function that must be called when the request ends. If org does not exist,
+// the store is shared by the requests that acquire it meanwhile and org is
+// created only when the store commits its first change, so requests that
+// fail leave no organization behind.
+func (o *Orgs) Acquire(org string) (*Store, func()) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if st, ok := o.stores[org]; ok {
+		return st, func() {}
+	}
+
+	p, ok := o.pending[org]
+	if !ok {
+		st := New(o.opts...)
+		st.org = org
+		st.changed = o.register
+		p = &pendingOrg{store: st}
+		o.pending[org] = p
+	}
+	p.refs++
+
+	var once sync.Once
+	return p.store, func() {
+		once.Do(func() {
+			o.mu.Lock()
+			defer o.mu.Unlock()
+			if p.refs--; p.refs == 0 && o.pending[org] == p {
+				delete(o.pending, org)
+			}
+		})
+	}
+}
+
+// register creates the organization of a store from Acquire. It runs with
+// the store's lock held, before the store delivers its first event.
+func (o *Orgs) register(st *Store) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.registerLocked(st)
+}
+
+func (o *Orgs) registerLocked(st *Store) {
+	if o.stores[st.org] == st {
+		return
+	}
+	for h := range o.handlers {
+		h.unsubs = append(h.unsubs, st.Subscribe(h.handle))
+	}
+	o.stores[st.org] = st
+}
+
+// List returns the stores of every organization, ordered by name.
+func (o *Orgs) List() []*Store {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.listLocked()
+}
+
+func (o *Orgs) listLocked() []*Store {
+	result := make([]*Store, 0, len(o.stores))
+	for _, st := range o.stores {
+		result = append(result, st)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].org < result[j].org
+	})
+	return result
+}
+
+// Subscribe registers handler with the store of every current and future
+// organization. Events carry the organization they belong to.
+func (o *Orgs) Subscribe(handler func(Event)) func() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	h := &orgHandler{handle: handler}
+	for _, st := range o.stores {
+		h.unsubs = append(h.unsubs, st.Subscribe(handler))
+	}
+	o.handlers[h] = struct{}{}
+
+	return func() {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		if _, ok := o.handlers[h]; !ok {
+			return
+		}
+		delete(o.handlers, h)
+		for _, unsub := range h.unsubs {
+			unsub()
+		}
+	}
+}
+
+// Org returns the organization the store belongs to.
+func (s *Store) Org() string {
+	return s.org
+}
+
+// Ready reports whether every organization's store can serve requests. The
+// in-memory backend has nothing to connect to or replay, so it is ready once
+// each store's lock can be taken; a store stuck behind a held lock fails the
+// check when ctx is done. Concurrent calls share one probe, so a stuck store
+// holds at most one waiting goroutine.
+func (o *Orgs) Ready(ctx context.Context) error {
+	o.mu.Lock()
+	if o.probe == nil {
+		o.probe = make(chan struct{})
+		go o.runProbe(o.probe, o.listLocked())
+	}
+	done := o.probe
+	o.mu.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("store is not responding: %w", ctx.Err())
+	}
+}
+
+func (o *Orgs) runProbe(done chan struct{}, stores []*Store) {
+	for _, st := range stores {
+		st.mu.RLock()
+		st.mu.RUnlock()
+	}
+
+	o.mu.Lock()
+	o.probe = nil
+	o.mu.Unlock()
+	close(done)
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOrgsLookupDoesNotCreate(t *testing.T) {
+	o := NewOrgs()
+
+	if _, ok := o.Lookup(DefaultOrg); !ok {
+		t.Fatalf("default organization is missing")
+	}
+	if _, ok := o.Lookup("acme"); ok {
+		t.Fatalf("acme exists before it was created")
+	}
+	if got := len(o.List()); got != 1 {
+		t.Fatalf("List() has %d stores after Lookup, want 1", got)
+	}
+
+	created := o.Get("acme")
+	if st, ok := o.Lookup("acme"); !ok || st != created {
+		t.Fatalf("Lookup after Get = %v, %v; want the created store", st, ok)
+	}
+	if created.Org() != "acme" {
+		t.Errorf("Org() = %s, want acme", created.Org())
+	}
+}
+
+func TestOrgsReady(t *testing.T) {
+	o := NewOrgs()
+	o.Get("acme")
+
+	if err := o.Ready(context.Background()); err != nil {
+		t.Fatalf("Ready() = %v, want nil", err)
+	}
+
+	stuck := o.Get("stuck")
+	stuck.mu.Lock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := o.Ready(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Ready() with a stuck store = %v, want deadline exceeded", err)
+	}
+
+	o.mu.Lock()
+	first := o.probe
+	o.mu.Unlock()
+	if first == nil {
+		t.Fatalf("no probe is waiting on the stuck store")
+	}
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		_ = o.Ready(ctx)
+		cancel()
+	}
+	o.mu.Lock()
+	again := o.probe
+	o.mu.Unlock()
+	if again != first {
+		t.Fatalf("Ready started a second probe while the first was waiting")
+	}
+
+	stuck.mu.Unlock()
+	<-first
+	if err := o.Ready(context.Background()); err != nil {
+		t.Fatalf("Ready() after unlock = %v, want nil", err)
+	}
+}
+
+func TestOrgsAcquire(t *testing.T) {
+	t.Run("existing organization", func(t *testing.T) {
+		o := NewOrgs()
+		st, release := o.Acquire(DefaultOrg)
+		defer release()
+		if want, _ := o.Lookup(DefaultOrg); st != want {
+			t.Fatalf("Acquire(%s) did not return the existing store", DefaultOrg)
+		}
+	})
+
+	t.Run("released without a change", func(t *testing.T) {
+		o := NewOrgs()
+		st, release := o.Acquire("acme")
+		if _, err := st.SetTeamLead("backend", "u1"); !errors.Is(err, ErrTeamNotFound) {
+			t.Fatalf("SetTeamLead on a missing team = %v, want ErrTeamNotFound", err)
+		}
+		release()
+		release()
+		if _, ok := o.Lookup("acme"); ok {
+			t.Fatalf("acme exists after a failed change")
+		}
+		if len(o.pending) != 0 {
+			t.Fatalf("%d pending organizations after release, want 0", len(o.pending))
+		}
+	})
+
+	t.Run("first change creates", func(t *testing.T) {
+		o := NewOrgs()
+		events := make(chan Event, 1)
+		o.Subscribe(func(e Event) { events <- e })
+
+		first, releaseFirst := o.Acquire("acme")
+		second, releaseSecond := o.Acquire("acme")
+		if first != second {
+			t.Fatalf("concurrent Acquire returned different stores")
+		}
+		mustCreateTeam(t, first, "backend", "u1", "u2")
+		releaseFirst()
+		releaseSecond()
+
+		if st, ok := o.Lookup("acme"); !ok || st != first {
+			t.Fatalf("Lookup(acme) = %v, %v; want the acquired store", st, ok)
+		}
+		select {
+		case e := <-events:
+			if _, ok := e.(TeamCreated); !ok {
+				t.Errorf("event = %s, want TeamCreated", e.Name())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("organization subscribers did not receive the first event")
+		}
+	})
+
+	t.Run("rolled back transaction does not create", func(t *testing.T) {
+		o := NewOrgs()
+		st, release := o.Acquire("acme")
+		defer release()
+		_ = st.Transaction(func(tx *Store) error {
+			mustCreateTeam(t, tx, "backend", "u1", "u2")
+			return errors.New("rollback")
+		})
+		if _, ok := o.Lookup("acme"); ok {
+			t.Fatalf("acme exists after a rolled back transaction")
+		}
+	})
+}
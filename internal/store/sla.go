@@ -0,0 +1,253 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+const DefaultReviewSLA = 24 * time.Hour
+
+var ErrInvalidSLA = errors.New("invalid review SLA")
+
+// ReviewerAssignment records when a reviewer was assigned and first acted.
+// PausedFor is the time the pull request spent closed before the reviewer
+// acted; it does not count towards the SLA.
+type ReviewerAssignment struct {
+	UserID        string
+	AssignedAt    time.Time
+	FirstActionAt *time.Time
+	PausedFor     time.Duration
+}
+
+// Waiting returns how long the reviewer has been waiting at now, not counting
+// the time the pull request spent closed.
+func (a ReviewerAssignment) Waiting(now time.Time) time.Duration {
+	return now.Sub(a.AssignedAt) - a.PausedFor
+}
+
+// PendingReview is a reviewer who has not acted yet. Paused is set while the
+// pull request is closed, when the SLA clock does not run.
+type PendingReview struct {
+	PullRequestID string
+	TeamName      string
+	UserID        string
+	AssignedAt    time.Time
+	PausedFor     time.Duration
+	Paused        bool
+}
+
+// Waiting returns how long the reviewer has been waiting at now, not counting
+// the time the pull request spent closed.
+func (p PendingReview) Waiting(now time.Time) time.Duration {
+	return now.Sub(p.AssignedAt) - p.PausedFor
+}
+
+type OverdueReviewer struct {
+	UserID     string
+	AssignedAt time.Time
+	Deadline   time.Time
+	Waiting    time.Duration
+}
+
+type OverduePullRequest struct {
+	PullRequestID   string
+	PullRequestName string
+	AuthorID        string
+	TeamName        string
+	ReviewSLA       time.Duration
+	Reviewers       []OverdueReviewer
+}
+
+func (s *Store) SetTeamReviewSLA(teamName string, sla time.Duration) (*Team, error) {
+	return s.SetTeamReviewSLAIf(teamName, sla, AnyVersion)
+}
+
+// SetTeamReviewSLAIf is SetTeamReviewSLA that fails with ErrVersionMismatch
+// unless the team is at version.
+func (s *Store) SetTeamReviewSLAIf(teamName string, sla time.Duration, version uint64) (*Team, error) {
+	if sla <= 0 {
+		return nil, ErrInvalidSLA
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	team, err := s.teamLocked(teamName, version)
+	if err != nil {
+		return nil, err
+	}
+	if team.ReviewSLA != sla {
+		team.ReviewSLA = sla
+		team.Version++
+		s.emitLocked(TeamReviewSLAChanged{Org: s.org, Team: s.buildTeamLocked(team), At: s.nowUTC()})
+	}
+	return s.buildTeamLocked(team), nil
+}
+
+// SubmitReview records that the reviewer acted on the pull request. Only the
+// first action counts towards the SLA; repeated submissions are accepted.
+func (s *Store) SubmitReview(prID, reviewerID string) (*PullRequest, error) {
+	return s.SubmitReviewIf(prID, reviewerID, AnyVersion)
+}
+
+// SubmitReviewIf is SubmitReview that fails with ErrVersionMismatch unless
+// the pull request is at version.
+func (s *Store) SubmitReviewIf(prID, reviewerID string, version uint64) (*PullRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr, err := s.pullRequestLocked(prID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkOpen(pr); err != nil {
+		return nil, err
+	}
+
+	index := reviewerIndex(pr.AssignedReviewers, reviewerID)
+	if index == -1 {
+		return nil, ErrReviewerNotAssigned
+	}
+
+	now := s.nowUTC()
+	if pr.Assignments[index].FirstActionAt == nil {
+		pr.Assignments[index].FirstActionAt = &now
+		pr.Version++
+	}
+	s.emitLocked(ReviewSubmitted{Org: s.org, PR: clonePullRequest(pr), TeamName: s.authorTeamNameLocked(pr), ReviewerID: reviewerID, At: now})
+
+	return clonePullRequest(pr), nil
+}
+
+// ListOverdueReviews returns open pull requests with reviewers who have not
+// acted within their team's SLA. An empty teamName covers all teams.
+func (s *Store) ListOverdueReviews(teamName string) ([]OverduePullRequest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if teamName != "" {
+		if _, ok := s.teams[teamName]; !ok {
+			return nil, ErrTeamNotFound
+		}
+	}
+
+	now := s.nowUTC()
+	result := make([]OverduePullRequest, 0)
+	for _, pr := range s.prs {
+		if pr.Status != StatusOpen {
+			continue
+		}
+
+		prTeam := s.authorTeamNameLocked(pr)
+		if teamName != "" && prTeam != teamName {
+			continue
+		}
+
+		sla := s.reviewSLALocked(prTeam)
+		var reviewers []OverdueReviewer
+		for _, a := range pr.Assignments {
+			if a.FirstActionAt != nil {
+				continue
+			}
+			deadline := a.AssignedAt.Add(a.PausedFor + sla)
+			if !now.After(deadline) {
+				continue
+			}
+			reviewers = append(reviewers, OverdueReviewer{
+				UserID:     a.UserID,
+				AssignedAt: a.AssignedAt,
+				Deadline:   deadline,
+				Waiting:    a.Waiting(now),
+			})
+		}
+		if len(reviewers) == 0 {
+			continue
+		}
+
+		result = append(result, OverduePullRequest{
+			PullRequestID:   pr.ID,
+			PullRequestName: pr.Name,
+			AuthorID:        pr.AuthorID,
+			TeamName:        prTeam,
+			ReviewSLA:       sla,
+			Reviewers:       reviewers,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PullRequestID < result[j].PullRequestID
+	})
+
+	return result, nil
+}
+
+// ListPendingReviews returns every reviewer on an open or closed pull request
+// who has not acted yet, ordered by pull request and reviewer. Reviews of
+// closed pull requests are marked Paused.
+func (s *Store) ListPendingReviews() []PendingReview {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]PendingReview, 0)
+	for _, pr := range s.prs {
+		if pr.Status == StatusMerged {
+			continue
+		}
+		teamName := s.authorTeamNameLocked(pr)
+		for _, a := range pr.Assignments {
+			if a.FirstActionAt != nil {
+				continue
+			}
+			result = append(result, PendingReview{
+				PullRequestID: pr.ID,
+				TeamName:      teamName,
+				UserID:        a.UserID,
+				AssignedAt:    a.AssignedAt,
+				PausedFor:     a.PausedFor,
+				Paused:        pr.Status == StatusClosed,
+			})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].PullRequestID != result[j].PullRequestID {
+			return result[i].PullRequestID < result[j].PullRequestID
+		}
+		return result[i].UserID < result[j].UserID
+	})
+
+	return result
+}
+
+func (s *Store) authorTeamNameLocked(pr *PullRequest) string {
+	if author := s.users[pr.AuthorID]; author != nil {
+		return author.TeamName
+	}
+	return ""
+}
+
+func (s *Store) reviewSLALocked(teamName string) time.Duration {
+	if team, ok := s.teams[teamName]; ok && team.ReviewSLA > 0 {
+		return team.ReviewSLA
+	}
+	return s.reviewSLA
+}
+
+func newAssignments(reviewers []string, at time.Time) []ReviewerAssignment {
+	assignments := make([]ReviewerAssignment, 0, len(reviewers))
+	for _, id := range reviewers {
+		assignments = append(assignments, ReviewerAssignment{UserID: id, AssignedAt: at})
+	}
+	return assignments
+}
+
+func cloneAssignment(a ReviewerAssignment) ReviewerAssignment {
+	clone := a
+	if a.FirstActionAt != nil {
+		ts := *a.FirstActionAt
+		clone.FirstActionAt = &ts
+	}
+	return clone
+}
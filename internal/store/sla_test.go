@@ -0,0 +1,157 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReopenPausesSLA(t *testing.T) {
+	clock := newTestClock()
+	s := New(WithClock(clock.Now), WithSelectionMode(SelectionDeterministic))
+	mustCreateTeam(t, s, "backend", "u1", "u2", "u3")
+	pr := mustCreatePR(t, s, "pr-1", "u1")
+	acted, waiting := pr.AssignedReviewers[0], pr.AssignedReviewers[1]
+
+	clock.Advance(2 * time.Hour)
+	if _, err := s.SubmitReview("pr-1", acted); err != nil {
+		t.Fatalf("SubmitReview: %v", err)
+	}
+	clock.Advance(8 * time.Hour)
+	if _, err := s.ClosePullRequest("pr-1"); err != nil {
+		t.Fatalf("ClosePullRequest: %v", err)
+	}
+	clock.Advance(72 * time.Hour)
+	if _, err := s.ReopenPullRequest("pr-1"); err != nil {
+		t.Fatalf("ReopenPullRequest: %v", err)
+	}
+
+	// The assignment time is kept; only the waiting reviewer is paused.
+	reopened, _ := s.GetPullRequest("pr-1")
+	for _, a := range reopened.Assignments {
+		var want time.Duration
+		if a.UserID == waiting {
+			want = 72 * time.Hour
+		}
+		if !a.AssignedAt.Equal(testStart) || a.PausedFor != want {
+			t.Errorf("%s assigned at %s paused for %s, want %s and %s", a.UserID, a.AssignedAt, a.PausedFor, testStart, want)
+		}
+	}
+
+	// A second close and reopen adds to the pause.
+	if _, err := s.ClosePullRequest("pr-1"); err != nil {
+		t.Fatalf("ClosePullRequest: %v", err)
+	}
+	pending := s.ListPendingReviews()
+	if len(pending) != 1 || !pending[0].Paused {
+		t.Fatalf("pending while closed = %+v, want %s paused", pending, waiting)
+	}
+	clock.Advance(time.Hour)
+	if _, err := s.ReopenPullRequest("pr-1"); err != nil {
+		t.Fatalf("ReopenPullRequest: %v", err)
+	}
+	pending = s.ListPendingReviews()
+	if len(pending) != 1 || pending[0].Paused || pending[0].PausedFor != 73*time.Hour || pending[0].Waiting(clock.Now()) != 10*time.Hour {
+		t.Fatalf("pending after reopening = %+v, want %s waiting 10h", pending, waiting)
+	}
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		overdue bool
+	}{
+		// 10h waited before closing; the 24h SLA has 14h left.
+		{"right after reopening", 0, false},
+		{"just before the deadline", 14 * time.Hour, false},
+		{"past the deadline", time.Minute, true},
+	}
+	for _, tt := range tests {
+		clock.Advance(tt.advance)
+		overdue, err := s.ListOverdueReviews("")
+		if err != nil {
+			t.Fatalf("ListOverdueReviews: %v", err)
+		}
+		if got := len(overdue) == 1; got != tt.overdue {
+			t.Fatalf("%s: overdue = %v, want %v", tt.name, overdue, tt.overdue)
+		}
+		if tt.overdue {
+			r := overdue[0].Reviewers
+			if len(r) != 1 || r[0].UserID != waiting || r[0].Waiting != 24*time.Hour+time.Minute {
+				t.Errorf("%s: reviewers = %+v, want %s waiting 24h1m", tt.name, r, waiting)
+			}
+		}
+	}
+}
+
+func TestListOverdueReviews(t *testing.T) {
+	clock := newTestClock()
+	s := New(WithClock(clock.Now), WithSelectionMode(SelectionDeterministic))
+	mustCreateTeam(t, s, "backend", "u1", "u2", "u3")
+	mustCreateTeam(t, s, "frontend", "u4", "u5")
+	if _, err := s.SetTeamReviewSLA("frontend", 4*time.Hour); err != nil {
+		t.Fatalf("SetTeamReviewSLA: %v", err)
+	}
+	mustCreatePR(t, s, "pr-1", "u1")
+	mustCreatePR(t, s, "pr-2", "u4")
+
+	tests := []struct {
+		name    string
+		at      time.Duration
+		team    string
+		wantIDs []string
+		wantErr error
+	}{
+		{"nothing due", 4 * time.Hour, "", nil, nil},
+		{"team SLA first", 5 * time.Hour, "", []string{"pr-2"}, nil},
+		{"default SLA later", 25 * time.Hour, "", []string{"pr-1", "pr-2"}, nil},
+		{"filtered by team", 25 * time.Hour, "backend", []string{"pr-1"}, nil},
+		{"unknown team", 25 * time.Hour, "ops", nil, ErrTeamNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.Set(testStart.Add(tt.at))
+
+			overdue, err := s.ListOverdueReviews(tt.team)
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			var ids []string
+			for _, pr := range overdue {
+				ids = append(ids, pr.PullRequestID)
+			}
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("overdue = %v, want %v", ids, tt.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Errorf("overdue = %v, want %v", ids, tt.wantIDs)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultReviewSLA(t *testing.T) {
+	clock := newTestClock()
+	s := New(WithClock(clock.Now), WithSelectionMode(SelectionDeterministic), WithDefaultReviewSLA(2*time.Hour))
+	mustCreateTeam(t, s, "backend", "u1", "u2", "u3")
+	mustCreateTeam(t, s, "frontend", "u4", "u5")
+	if _, err := s.SetTeamReviewSLA("frontend", 8*time.Hour); err != nil {
+		t.Fatalf("SetTeamReviewSLA: %v", err)
+	}
+	mustCreatePR(t, s, "pr-1", "u1")
+	mustCreatePR(t, s, "pr-2", "u4")
+
+	clock.Set(testStart.Add(3 * time.Hour))
+	overdue, err := s.ListOverdueReviews("")
+	if err != nil {
+		t.Fatalf("ListOverdueReviews: %v", err)
+	}
+	if len(overdue) != 1 || overdue[0].PullRequestID != "pr-1" {
+		t.Fatalf("overdue = %+v, want only pr-1 under the 2h default", overdue)
+	}
+	for _, r := range overdue[0].Reviewers {
+		if want := testStart.Add(2 * time.Hour); !r.Deadline.Equal(want) {
+			t.Errorf("%s deadline = %s, want %s", r.UserID, r.Deadline, want)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package store
+
+import (
+	"sort"
+	"time"
+)
+
+// Window bounds statistics by time inclusively. A zero From or To leaves that
+// side open.
+type Window struct {
+	From time.Time
+	To   time.Time
+}
+
+func (w Window) contains(t time.Time) bool {
+	if !w.From.IsZero() && t.Before(w.From) {
+		return false
+	}
+	if !w.To.IsZero() && t.After(w.To) {
+		return false
+	}
+	return true
+}
+
+// ReviewStats summarizes review activity. Assigned, Merged and Reassigned
+// count events inside the window; Open is the current load.
+type ReviewStats struct {
+	Assigned int
+	Open     int
+	Merged   int
+	// MedianTimeToMerge is measured from creation to merge of the merged
+	// pull requests. It is zero when Merged is zero.
+	MedianTimeToMerge time.Duration
+	Reassigned        int
+}
+
+// UserReviewStats describes a user as a reviewer: reviews assigned to them,
+// open reviews waiting for them, pull requests they reviewed that were merged
+// and how often they were replaced.
+type UserReviewStats struct {
+	UserID   string
+	TeamName string
+	ReviewStats
+}
+
+// TeamReviewStats describes pull requests authored by a team's current
+// members: reviewer assignments on them, open ones, merges and reviewer
+// replacements.
+type TeamReviewStats struct {
+	TeamName string
+	ReviewStats
+}
+
+type statsAccumulator struct {
+	ReviewStats
+	mergeTimes []time.Duration
+}
+
+func (a *statsAccumulator) result() ReviewStats {
+	stats := a.ReviewStats
+	stats.MedianTimeToMerge = median(a.mergeTimes)
+	return stats
+}
+
+// UserStats returns review statistics of every user, ordered by user ID.
+func (s *Store) UserStats(window Window) []UserReviewStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	acc := make(map[string]*statsAccumulator, len(s.users))
+	for id := range s.users {
+		acc[id] = &statsAccumulator{}
+	}
+
+	s.walkStatsLocked(window, statsVisitor{
+		assigned: func(pr *PullRequest, userID string) {
+			if a, ok := acc[userID]; ok {
+				a.Assigned++
+			}
+		},
+		replaced: func(pr *PullRequest, userID string) {
+			if a, ok := acc[userID]; ok {
+				a.Reassigned++
+			}
+		},
+		open: func(pr *PullRequest) {
+			for _, id := range pr.AssignedReviewers {
+				if a, ok := acc[id]; ok {
+					a.Open++
+				}
+			}
+		},
+		merged: func(pr *PullRequest, took time.Duration) {
+			for _, id := range pr.AssignedReviewers {
+				if a, ok := acc[id]; ok {
+					a.Merged++
+					a.mergeTimes = append(a.mergeTimes, took)
+				}
+			}
+		},
+	})
+
+	result := make([]UserReviewStats, 0, len(acc))
+	for id, a := range acc {
+		result = append(result, UserReviewStats{
+			UserID:      id,
+			TeamName:    s.users[id].TeamName,
+			ReviewStats: a.result(),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UserID < result[j].UserID
+	})
+	return result
+}
+
+// TeamStats returns review statistics of every team, ordered by name. Pull
+// requests count towards the current team of their author.
+func (s *Store) TeamStats(window Window) []TeamReviewStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	acc := make(map[string]*statsAccumulator, len(s.teams))
+	for name := range s.teams {
+		acc[name] = &statsAccumulator{}
+	}
+	team := func(pr *PullRequest) *statsAccumulator {
+		return acc[s.authorTeamNameLocked(pr)]
+	}
+
+	s.walkStatsLocked(window, statsVisitor{
+		assigned: func(pr *PullRequest, _ string) {
+			if a := team(pr); a != nil {
+				a.Assigned++
+			}
+		},
+		replaced: func(pr *PullRequest, _ string) {
+			if a := team(pr); a != nil {
+				a.Reassigned++
+			}
+		},
+		open: func(pr *PullRequest) {
+			if a := team(pr); a != nil {
+				a.Open++
+			}
+		},
+		merged: func(pr *PullRequest, took time.Duration) {
+			if a := team(pr); a != nil {
+				a.Merged++
+				a.mergeTimes = append(a.mergeTimes, took)
+			}
+		},
+	})
+
+	result := make([]TeamReviewStats, 0, len(acc))
+	for name, a := range acc {
+		result = append(result, TeamReviewStats{TeamName: name, ReviewStats: a.result()})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].TeamName < result[j].TeamName
+	})
+	return result
+}
+
+type statsVisitor struct {
+	assigned func(pr *PullRequest, userID string)
+	replaced func(pr *PullRequest, userID string)
+	open     func(pr *PullRequest)
+	merged   func(pr *PullRequest, took time.Duration)
+}
+
+// walkStatsLocked reports assignments and replacements from the assignment
+// history, and open and merged pull requests from their current state.
+func (s *Store) walkStatsLocked(window Window, v statsVisitor) {
+	for id, pr := range s.prs {
+		for _, e := range s.explanations[id] {
+			if !window.contains(e.At) {
+				continue
+			}
+			for _, userID := range e.Selected {
+				v.assigned(pr, userID)
+			}
+			if e.Kind == AssignmentReassign && e.Replaced != "" {
+				v.replaced(pr, e.Replaced)
+			}
+		}
+
+		switch {
+		case pr.Status == StatusOpen:
+			v.open(pr)
+		case pr.Status == StatusMerged && pr.MergedAt != nil && window.contains(*pr.MergedAt):
+			v.merged(pr, pr.MergedAt.Sub(pr.CreatedAt))
+		}
+	}
+}
+
+func median(values []time.Duration) time.Duration {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
+
+// ReviewLoad is the open work of a store at one moment.
+type ReviewLoad struct {
+	// OpenByTeam counts open pull requests per author team, including teams
+	// without any.
+	OpenByTeam map[string]int
+	// OpenReviewsByUser counts open pull requests each user is assigned to,
+	// including users without any.
+	OpenReviewsByUser map[string]int
+	// WithoutReviewers counts open pull requests nobody is assigned to.
+	WithoutReviewers int
+}
+
+func (s *Store) ReviewLoad() ReviewLoad {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	load := ReviewLoad{
+		OpenByTeam:        make(map[string]int, len(s.teams)),
+		OpenReviewsByUser: make(map[string]int, len(s.users)),
+	}
+	for name := range s.teams {
+		load.OpenByTeam[name] = 0
+	}
+	for id := range s.users {
+		load.OpenReviewsByUser[id] = 0
+	}
+
+	for _, pr := range s.prs {
+		if pr.Status != StatusOpen {
+			continue
+		}
+		if team := s.authorTeamNameLocked(pr); team != "" {
+			load.OpenByTeam[team]++
+		}
+		if len(pr.AssignedReviewers) == 0 {
+			load.WithoutReviewers++
+		}
+		for _, id := range pr.AssignedReviewers {
+			load.OpenReviewsByUser[id]++
+		}
+	}
+	return load
+}
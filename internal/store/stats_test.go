@@ -0,0 +1,103 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []time.Duration
+		want   time.Duration
+	}{
+		{"empty", nil, 0},
+		{"one", []time.Duration{3 * time.Hour}, 3 * time.Hour},
+		{"odd count", []time.Duration{5 * time.Hour, time.Hour, 2 * time.Hour}, 2 * time.Hour},
+		{"even count", []time.Duration{4 * time.Hour, time.Hour, 3 * time.Hour, 2 * time.Hour}, 150 * time.Minute},
+		{"even count of equal values", []time.Duration{time.Hour, time.Hour}, time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := append([]time.Duration(nil), tt.values...)
+			if got := median(values); got != tt.want {
+				t.Errorf("median(%v) = %s, want %s", tt.values, got, tt.want)
+			}
+			for i := range values {
+				if values[i] != tt.values[i] {
+					t.Fatalf("median reordered its input to %v", values)
+				}
+			}
+		})
+	}
+}
+
+// mergeAfter opens a pull request by authorID and merges it took later.
+func mergeAfter(t *testing.T, s *Store, clock *testClock, id, authorID string, took time.Duration) {
+	t.Helper()
+	mustCreatePR(t, s, id, authorID)
+	clock.Advance(took)
+	if _, err := s.MergePullRequest(id); err != nil {
+		t.Fatalf("MergePullRequest(%s): %v", id, err)
+	}
+}
+
+func TestTeamStats(t *testing.T) {
+	clock := newTestClock()
+	s := New(WithClock(clock.Now), WithSelectionMode(SelectionDeterministic))
+	mustCreateTeam(t, s, "backend", "u1", "u2", "u3", "u4")
+	mustCreateTeam(t, s, "empty")
+
+	mergeAfter(t, s, clock, "pr-1", "u1", time.Hour)
+	mergeAfter(t, s, clock, "pr-2", "u2", 4*time.Hour)
+	mustCreatePR(t, s, "pr-3", "u3")
+
+	stats := s.TeamStats(Window{})
+	if len(stats) != 2 || stats[0].TeamName != "backend" || stats[1].TeamName != "empty" {
+		t.Fatalf("teams = %+v, want backend and empty", stats)
+	}
+	want := ReviewStats{Assigned: 6, Open: 1, Merged: 2, MedianTimeToMerge: 150 * time.Minute}
+	if stats[0].ReviewStats != want {
+		t.Errorf("backend with two merges = %+v, want %+v", stats[0].ReviewStats, want)
+	}
+	if stats[1].ReviewStats != (ReviewStats{}) {
+		t.Errorf("empty team = %+v, want zero stats", stats[1].ReviewStats)
+	}
+
+	mergeAfter(t, s, clock, "pr-4", "u1", 10*time.Hour)
+	want = ReviewStats{Assigned: 8, Open: 1, Merged: 3, MedianTimeToMerge: 4 * time.Hour}
+	if got := s.TeamStats(Window{})[0].ReviewStats; got != want {
+		t.Errorf("backend with three merges = %+v, want %+v", got, want)
+	}
+
+	// Every assignment happened before the window and only pr-4 was merged
+	// inside it; pr-3 is open whatever the window.
+	later := Window{From: testStart.Add(5*time.Hour + time.Minute)}
+	want = ReviewStats{Open: 1, Merged: 1, MedianTimeToMerge: 10 * time.Hour}
+	if got := s.TeamStats(later)[0].ReviewStats; got != want {
+		t.Errorf("backend after pr-2 = %+v, want %+v", got, want)
+	}
+}
+
+func TestUserStats(t *testing.T) {
+	clock := newTestClock()
+	s := New(WithClock(clock.Now), WithSelectionMode(SelectionDeterministic))
+	mustCreateTeam(t, s, "backend", "u1", "u2", "u3")
+
+	mergeAfter(t, s, clock, "pr-1", "u1", time.Hour)
+	mergeAfter(t, s, clock, "pr-2", "u1", 3*time.Hour)
+
+	stats := s.UserStats(Window{})
+	if len(stats) != 3 {
+		t.Fatalf("users = %+v, want u1, u2 and u3", stats)
+	}
+	if stats[0].UserID != "u1" || stats[0].ReviewStats != (ReviewStats{}) {
+		t.Errorf("author = %+v, want no review stats", stats[0])
+	}
+	want := ReviewStats{Assigned: 2, Merged: 2, MedianTimeToMerge: 2 * time.Hour}
+	for _, u := range stats[1:] {
+		if u.TeamName != "backend" || u.ReviewStats != want {
+			t.Errorf("%s = %+v, want %+v in backend", u.UserID, u, want)
+		}
+	}
+}
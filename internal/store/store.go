@@ -11,19 +11,33 @@ import (
 const (
 	StatusOpen   = "OPEN"
 	StatusMerged = "MERGED"
+	StatusClosed = "CLOSED"
 )
 
 var (
-	ErrTeamExists             = errors.New("team already exists")
-	ErrTeamNotFound           = errors.New("team not found")
-	ErrUserNotFound           = errors.New("user not found")
-	ErrPullRequestExists      = errors.New("pull request already exists")
-	ErrPullRequestNotFound    = errors.New("pull request not found")
-	ErrPullRequestMerged      = errors.New("pull request merged")
-	ErrReviewerNotAssigned    = errors.New("reviewer not assigned")
-	ErrNoReplacementCandidate = errors.New("no replacement candidate")
+	ErrTeamExists                  = errors.New("team already exists")
+	ErrTeamNotFound                = errors.New("team not found")
+	ErrUserNotFound                = errors.New("user not found")
+	ErrPullRequestExists           = errors.New("pull request already exists")
+	ErrPullRequestNotFound         = errors.New("pull request not found")
+	ErrPullRequestMerged           = errors.New("pull request merged")
+	ErrPullRequestClosed           = errors.New("pull request closed")
+	ErrReviewerNotAssigned         = errors.New("reviewer not assigned")
+	ErrNoReplacementCandidate      = errors.New("no replacement candidate")
+	ErrReviewerAlreadyAssigned     = errors.New("reviewer already assigned")
+	ErrReviewerInactive            = errors.New("reviewer inactive")
+	ErrReviewerIsAuthor            = errors.New("reviewer is author")
+	ErrReviewerAtCapacity          = errors.New("reviewer at capacity")
+	ErrPreferredReviewerIneligible = errors.New("preferred reviewer ineligible")
+	ErrUserNotInTeam               = errors.New("user not in team")
+	ErrUsernameAmbiguous           = errors.New("username ambiguous")
+	ErrVersionMismatch             = errors.New("version mismatch")
 )
 
+// AnyVersion makes a conditional method apply regardless of the current
+// version.
+const AnyVersion uint64 = 0
+
 type TeamMemberInput struct {
 	UserID   string
 	Username string
@@ -32,7 +46,13 @@ type TeamMemberInput struct {
 
 type Team struct {
 	Name    string
+	LeadID  string
 	Members []TeamMember
+	// ReviewSLA is the team's own deadline, zero when it uses the default.
+	ReviewSLA time.Duration
+	// Version grows with every change to the team, including its members'
+	// activity.
+	Version uint64
 }
 
 type TeamMember struct {
@@ -54,30 +74,71 @@ type PullRequest struct {
 	AuthorID          string
 	Status            string
 	AssignedReviewers []string
+	Assignments       []ReviewerAssignment
 	CreatedAt         time.Time
 	MergedAt          *time.Time
+	ClosedAt          *time.Time
+	// Version grows with every change to the pull request.
+	Version uint64
 }
 
 type Store struct {
+	org   string
 	mu    sync.RWMutex
 	teams map[string]*teamRecord
 	users map[string]*User
 	prs   map[string]*PullRequest
 	rnd   *rand.Rand
+
+	now       func() time.Time
+	seed      int64
+	selection string
+	reviewSLA time.Duration
+	// maxOpenReviews caps the open pull requests a reviewer is assigned to;
+	// zero means no limit.
+	maxOpenReviews int
+
+	explanations map[string][]AssignmentExplanation
+
+	bus bus
+	// buffered stores of a transaction collect events in pending until
+	// commit.
+	buffered bool
+	pending  []Event
+	// changed is called before the first event is delivered; Orgs uses it
+	// to create an organization on its first committed change.
+	changed func(*Store)
 }
 
 type teamRecord struct {
-	Name    string
-	Members map[string]struct{}
+	Name      string
+	Members   map[string]struct{}
+	LeadID    string
+	ReviewSLA time.Duration
+	Version   uint64
 }
 
-func New() *Store {
-	return &Store{
+func New(opts ...Option) *Store {
+	s := &Store{
+		org:   DefaultOrg,
 		teams: make(map[string]*teamRecord),
 		users: make(map[string]*User),
 		prs:   make(map[string]*PullRequest),
-		rnd:   rand.New(rand.NewSource(time.Now().UnixNano())),
+
+		now:       time.Now,
+		seed:      time.Now().UnixNano(),
+		selection: SelectionRandom,
+		reviewSLA: DefaultReviewSLA,
+
+		explanations: make(map[string][]AssignmentExplanation),
+
+		bus: bus{subs: make(map[*busSubscriber]struct{})},
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	s.rnd = rand.New(rand.NewSource(s.seed))
+	return s
 }
 
 func (s *Store) CreateTeam(name string, members []TeamMemberInput) (*Team, error) {
@@ -91,6 +152,7 @@ func (s *Store) CreateTeam(name string, members []TeamMemberInput) (*Team, error
 	record := &teamRecord{
 		Name:    name,
 		Members: make(map[string]struct{}),
+		Version: 1,
 	}
 	s.teams[name] = record
 
@@ -102,7 +164,9 @@ func (s *Store) CreateTeam(name string, members []TeamMemberInput) (*Team, error
 		record.Members[u.ID] = struct{}{}
 	}
 
-	return s.buildTeamLocked(record), nil
+	team := s.buildTeamLocked(record)
+	s.emitLocked(TeamCreated{Org: s.org, Team: s.buildTeamLocked(record), At: s.nowUTC()})
+	return team, nil
 }
 
 func (s *Store) GetTeam(name string) (*Team, error) {
@@ -117,6 +181,49 @@ func (s *Store) GetTeam(name string) (*Team, error) {
 	return s.buildTeamLocked(record), nil
 }
 
+func (s *Store) SetTeamLead(teamName, userID string) (*Team, error) {
+	return s.SetTeamLeadIf(teamName, userID, AnyVersion)
+}
+
+// SetTeamLeadIf is SetTeamLead that fails with ErrVersionMismatch unless the
+// team is at version.
+func (s *Store) SetTeamLeadIf(teamName, userID string, version uint64) (*Team, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, err := s.teamLocked(teamName, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := s.users[userID]; !ok {
+		return nil, ErrUserNotFound
+	}
+	if _, member := record.Members[userID]; !member {
+		return nil, ErrUserNotInTeam
+	}
+
+	if record.LeadID != userID {
+		record.LeadID = userID
+		record.Version++
+		s.emitLocked(TeamLeadChanged{Org: s.org, Team: s.buildTeamLocked(record), At: s.nowUTC()})
+	}
+	return s.buildTeamLocked(record), nil
+}
+
+// teamLocked looks up a team and checks its version unless version is
+// AnyVersion.
+func (s *Store) teamLocked(name string, version uint64) (*teamRecord, error) {
+	record, ok := s.teams[name]
+	if !ok {
+		return nil, ErrTeamNotFound
+	}
+	if version != AnyVersion && record.Version != version {
+		return nil, ErrVersionMismatch
+	}
+	return record, nil
+}
+
 func (s *Store) upsertUserLocked(id, username, teamName string, isActive bool) *User {
 	user, ok := s.users[id]
 	if !ok {
@@ -127,6 +234,10 @@ func (s *Store) upsertUserLocked(id, username, teamName string, isActive bool) *
 	if user.TeamName != "" && user.TeamName != teamName {
 		if oldTeam, ok := s.teams[user.TeamName]; ok {
 			delete(oldTeam.Members, user.ID)
+			if oldTeam.LeadID == user.ID {
+				oldTeam.LeadID = ""
+			}
+			oldTeam.Version++
 		}
 	}
 
@@ -138,15 +249,38 @@ func (s *Store) upsertUserLocked(id, username, teamName string, isActive bool) *
 }
 
 func (s *Store) SetUserActive(userID string, isActive bool) (*User, error) {
+	user, _, err := s.SetUserActiveIf(userID, isActive, AnyVersion)
+	return user, err
+}
+
+// SetUserActiveIf is SetUserActive that fails with ErrVersionMismatch unless
+// the user's team is at version, since the change bumps the team's version.
+// It also returns the team's version after the change, or zero for a user
+// without a team.
+func (s *Store) SetUserActiveIf(userID string, isActive bool, version uint64) (*User, uint64, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	user, ok := s.users[userID]
 	if !ok {
-		return nil, ErrUserNotFound
+		return nil, 0, ErrUserNotFound
 	}
-	user.IsActive = isActive
-	return cloneUser(user), nil
+	team, ok := s.teams[user.TeamName]
+	if version != AnyVersion && (!ok || team.Version != version) {
+		return nil, 0, ErrVersionMismatch
+	}
+	if user.IsActive != isActive {
+		user.IsActive = isActive
+		if ok {
+			team.Version++
+		}
+		s.emitLocked(UserActivityChanged{Org: s.org, User: cloneUser(user), At: s.nowUTC()})
+	}
+	var teamVersion uint64
+	if ok {
+		teamVersion = team.Version
+	}
+	return cloneUser(user), teamVersion, nil
 }
 
 func (s *Store) GetUser(userID string) (*User, error) {
@@ -160,6 +294,27 @@ func (s *Store) GetUser(userID string) (*User, error) {
 	return cloneUser(user), nil
 }
 
+// FindUserByUsername resolves a username to the single user carrying it.
+func (s *Store) FindUserByUsername(username string) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found *User
+	for _, user := range s.users {
+		if user.Username != username {
+			continue
+		}
+		if found != nil {
+			return nil, ErrUsernameAmbiguous
+		}
+		found = user
+	}
+	if found == nil {
+		return nil, ErrUserNotFound
+	}
+	return cloneUser(found), nil
+}
+
 type CreatePullRequestInput struct {
 	ID       string
 	Name     string
@@ -174,65 +329,100 @@ func (s *Store) CreatePullRequest(input CreatePullRequestInput) (*PullRequest, e
 		return nil, ErrPullRequestExists
 	}
 
-	author, ok := s.users[input.AuthorID]
-	if !ok {
-		return nil, ErrUserNotFound
+	author, team, err := s.authorTeamLocked(input.AuthorID)
+	if err != nil {
+		return nil, err
 	}
 
-	if author.TeamName == "" {
-		return nil, ErrTeamNotFound
-	}
+	reviewers, explanation := s.pickReviewersLocked(team, input.ID, author.ID, s.prRand(input.ID))
 
-	team, ok := s.teams[author.TeamName]
-	if !ok {
-		return nil, ErrTeamNotFound
-	}
-
-	reviewers := s.pickReviewersLocked(team, author.ID)
-
-	now := time.Now().UTC()
+	now := s.nowUTC()
 	pr := &PullRequest{
 		ID:                input.ID,
 		Name:              input.Name,
 		AuthorID:          author.ID,
 		Status:            StatusOpen,
 		AssignedReviewers: reviewers,
+		Assignments:       newAssignments(reviewers, now),
 		CreatedAt:         now,
+		Version:           1,
 	}
 
 	s.prs[pr.ID] = pr
+	explanation.At = now
+	s.recordExplanationLocked(pr.ID, explanation)
+	s.emitLocked(PRCreated{Org: s.org, PR: clonePullRequest(pr), TeamName: author.TeamName, At: now})
 	return clonePullRequest(pr), nil
 }
 
-func (s *Store) pickReviewersLocked(team *teamRecord, authorID string) []string {
-	candidates := make([]string, 0, len(team.Members))
-	for memberID := range team.Members {
-		if memberID == authorID {
-			continue
-		}
-		user := s.users[memberID]
-		if user == nil || !user.IsActive {
-			continue
-		}
-		candidates = append(candidates, memberID)
+type PullRequestPreview struct {
+	ID         string
+	AuthorID   string
+	Strategy   string
+	Reviewers  []string
+	Candidates []string
+	Excluded   []CandidateExclusion
+}
+
+// PreviewPullRequest runs reviewer selection for a pull request that has not
+// been created yet without changing the store. Creating the pull request
+// before the team changes assigns the same reviewers in either mode.
+func (s *Store) PreviewPullRequest(input CreatePullRequestInput) (*PullRequestPreview, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, exists := s.prs[input.ID]; exists {
+		return nil, ErrPullRequestExists
 	}
 
-	if len(candidates) == 0 {
-		return nil
+	author, team, err := s.authorTeamLocked(input.AuthorID)
+	if err != nil {
+		return nil, err
 	}
 
-	s.rnd.Shuffle(len(candidates), func(i, j int) {
-		candidates[i], candidates[j] = candidates[j], candidates[i]
-	})
+	reviewers, explanation := s.pickReviewersLocked(team, input.ID, author.ID, s.prRand(input.ID))
 
-	limit := 2
-	if len(candidates) < limit {
-		limit = len(candidates)
+	return &PullRequestPreview{
+		ID:         input.ID,
+		AuthorID:   author.ID,
+		Strategy:   explanation.Strategy,
+		Reviewers:  reviewers,
+		Candidates: explanation.Candidates,
+		Excluded:   explanation.Excluded,
+	}, nil
+}
+
+func (s *Store) authorTeamLocked(authorID string) (*User, *teamRecord, error) {
+	author, ok := s.users[authorID]
+	if !ok {
+		return nil, nil, ErrUserNotFound
 	}
 
-	reviewers := make([]string, 0, limit)
-	reviewers = append(reviewers, candidates[:limit]...)
-	return reviewers
+	if author.TeamName == "" {
+		return nil, nil, ErrTeamNotFound
+	}
+
+	team, ok := s.teams[author.TeamName]
+	if !ok {
+		return nil, nil, ErrTeamNotFound
+	}
+
+	return author, team, nil
+}
+
+func (s *Store) pickReviewersLocked(team *teamRecord, prID, authorID string, rnd *rand.Rand) ([]string, AssignmentExplanation) {
+	candidates, excluded := s.evaluateCandidatesLocked(team, authorID, nil, "")
+	reviewers := s.selectLocked(rnd, candidates, 2, prID)
+
+	explanation := AssignmentExplanation{
+		Kind:       AssignmentCreate,
+		Strategy:   s.autoStrategy(),
+		Candidates: candidates,
+		Excluded:   excluded,
+		Selected:   append([]string(nil), reviewers...),
+		TeamName:   team.Name,
+	}
+	return reviewers, explanation
 }
 
 func (s *Store) GetPullRequest(prID string) (*PullRequest, error) {
@@ -247,55 +437,175 @@ func (s *Store) GetPullRequest(prID string) (*PullRequest, error) {
 }
 
 func (s *Store) MergePullRequest(prID string) (*PullRequest, error) {
+	return s.MergePullRequestIf(prID, AnyVersion)
+}
+
+// MergePullRequestIf is MergePullRequest that fails with ErrVersionMismatch unless the
+// pull request is at version.
+func (s *Store) MergePullRequestIf(prID string, version uint64) (*PullRequest, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	pr, ok := s.prs[prID]
-	if !ok {
-		return nil, ErrPullRequestNotFound
+	pr, err := s.pullRequestLocked(prID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if pr.Status == StatusClosed {
+		return nil, ErrPullRequestClosed
 	}
 
 	if pr.Status != StatusMerged {
 		pr.Status = StatusMerged
-		now := time.Now().UTC()
+		now := s.nowUTC()
 		if pr.MergedAt == nil {
 			pr.MergedAt = &now
 		}
+		pr.Version++
+		s.emitLocked(PRMerged{Org: s.org, PR: clonePullRequest(pr), TeamName: s.authorTeamNameLocked(pr), At: now})
 	}
 
 	return clonePullRequest(pr), nil
 }
 
-type ReassignResult struct {
-	PR         *PullRequest
-	ReplacedBy string
+// ClosePullRequest marks an open pull request as closed without merging.
+// Closing an already closed pull request is a no-op.
+func (s *Store) ClosePullRequest(prID string) (*PullRequest, error) {
+	return s.ClosePullRequestIf(prID, AnyVersion)
 }
 
-func (s *Store) ReassignReviewer(prID, oldReviewerID string) (*ReassignResult, error) {
+// ClosePullRequestIf is ClosePullRequest that fails with ErrVersionMismatch unless the
+// pull request is at version.
+func (s *Store) ClosePullRequestIf(prID string, version uint64) (*PullRequest, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	pr, err := s.pullRequestLocked(prID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	switch pr.Status {
+	case StatusMerged:
+		return nil, ErrPullRequestMerged
+	case StatusOpen:
+		now := s.nowUTC()
+		pr.Status = StatusClosed
+		pr.ClosedAt = &now
+		pr.Version++
+		s.emitLocked(PRClosed{Org: s.org, PR: clonePullRequest(pr), TeamName: s.authorTeamNameLocked(pr), At: now})
+	}
+
+	return clonePullRequest(pr), nil
+}
+
+// ReopenPullRequest returns a closed pull request to OPEN. Reviewers keep
+// their assignments; the SLA clock of those who have not acted yet was
+// paused while it was closed. Reopening an open pull request is a no-op.
+func (s *Store) ReopenPullRequest(prID string) (*PullRequest, error) {
+	return s.ReopenPullRequestIf(prID, AnyVersion)
+}
+
+// ReopenPullRequestIf is ReopenPullRequest that fails with ErrVersionMismatch unless the
+// pull request is at version.
+func (s *Store) ReopenPullRequestIf(prID string, version uint64) (*PullRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr, err := s.pullRequestLocked(prID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	switch pr.Status {
+	case StatusMerged:
+		return nil, ErrPullRequestMerged
+	case StatusClosed:
+		now := s.nowUTC()
+		if pr.ClosedAt != nil {
+			paused := now.Sub(*pr.ClosedAt)
+			for i := range pr.Assignments {
+				if pr.Assignments[i].FirstActionAt == nil {
+					pr.Assignments[i].PausedFor += paused
+				}
+			}
+		}
+		pr.Status = StatusOpen
+		pr.ClosedAt = nil
+		pr.Version++
+		s.emitLocked(PRReopened{Org: s.org, PR: clonePullRequest(pr), TeamName: s.authorTeamNameLocked(pr), At: now})
+	}
+
+	return clonePullRequest(pr), nil
+}
+
+// pullRequestLocked looks up a pull request and checks its version unless
+// version is AnyVersion.
+func (s *Store) pullRequestLocked(prID string, version uint64) (*PullRequest, error) {
 	pr, ok := s.prs[prID]
 	if !ok {
 		return nil, ErrPullRequestNotFound
 	}
+	if version != AnyVersion && pr.Version != version {
+		return nil, ErrVersionMismatch
+	}
+	return pr, nil
+}
 
-	if pr.Status == StatusMerged {
-		return nil, ErrPullRequestMerged
+func checkOpen(pr *PullRequest) error {
+	switch pr.Status {
+	case StatusMerged:
+		return ErrPullRequestMerged
+	case StatusClosed:
+		return ErrPullRequestClosed
 	}
+	return nil
+}
 
-	index := -1
-	for i, reviewer := range pr.AssignedReviewers {
-		if reviewer == oldReviewerID {
-			index = i
-			break
-		}
+type ReassignResult struct {
+	PR         *PullRequest
+	ReplacedBy string
+}
+
+type ReassignReviewerInput struct {
+	PullRequestID string
+	OldReviewerID string
+	// Preferred lists replacement candidates in order of preference. The
+	// first eligible one is chosen.
+	Preferred []string
+	// FallbackToAuto picks a random eligible candidate when none of the
+	// preferred users can be assigned. It is ignored when Preferred is empty.
+	FallbackToAuto bool
+	// Automatic marks replacements made by the service itself rather than
+	// requested by a user.
+	Automatic bool
+}
+
+func (s *Store) ReassignReviewer(input ReassignReviewerInput) (*ReassignResult, error) {
+	return s.ReassignReviewerIf(input, AnyVersion)
+}
+
+// ReassignReviewerIf is ReassignReviewer that fails with ErrVersionMismatch unless the
+// pull request is at version.
+func (s *Store) ReassignReviewerIf(input ReassignReviewerInput, version uint64) (*ReassignResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr, err := s.pullRequestLocked(input.PullRequestID, version)
+	if err != nil {
+		return nil, err
 	}
+
+	if err := checkOpen(pr); err != nil {
+		return nil, err
+	}
+
+	index := reviewerIndex(pr.AssignedReviewers, input.OldReviewerID)
 	if index == -1 {
 		return nil, ErrReviewerNotAssigned
 	}
 
-	reviewer, ok := s.users[oldReviewerID]
+	reviewer, ok := s.users[input.OldReviewerID]
 	if !ok {
 		return nil, ErrUserNotFound
 	}
@@ -308,40 +618,167 @@ func (s *Store) ReassignReviewer(prID, oldReviewerID string) (*ReassignResult, e
 		return nil, ErrTeamNotFound
 	}
 
-	candidates := s.pickReplacementCandidatesLocked(team, pr.AuthorID, pr.AssignedReviewers, oldReviewerID)
-	if len(candidates) == 0 {
-		return nil, ErrNoReplacementCandidate
+	candidates, excluded := s.evaluateCandidatesLocked(team, pr.AuthorID, pr.AssignedReviewers, input.OldReviewerID)
+
+	strategy := s.autoStrategy()
+	replacement := ""
+	if len(input.Preferred) > 0 {
+		strategy = StrategyPreferred
+		replacement = choosePreferred(candidates, input.Preferred)
+		if replacement == "" {
+			if !input.FallbackToAuto {
+				return nil, ErrPreferredReviewerIneligible
+			}
+			strategy = StrategyPreferredFallback
+		}
+	}
+
+	if replacement == "" {
+		if len(candidates) == 0 {
+			return nil, ErrNoReplacementCandidate
+		}
+		replacement = s.selectLocked(s.rnd, candidates, 1, pr.ID+"/"+input.OldReviewerID)[0]
 	}
 
-	replacement := candidates[s.rnd.Intn(len(candidates))]
+	now := s.nowUTC()
 	pr.AssignedReviewers[index] = replacement
+	pr.Assignments[index] = ReviewerAssignment{UserID: replacement, AssignedAt: now}
+	pr.Version++
+	s.recordExplanationLocked(pr.ID, AssignmentExplanation{
+		Kind:       AssignmentReassign,
+		Strategy:   strategy,
+		At:         now,
+		Candidates: candidates,
+		Excluded:   excluded,
+		Selected:   []string{replacement},
+		Replaced:   input.OldReviewerID,
+		TeamName:   team.Name,
+	})
+	s.emitLocked(ReviewerReassigned{
+		Org:           s.org,
+		PR:            clonePullRequest(pr),
+		TeamName:      s.authorTeamNameLocked(pr),
+		OldReviewerID: input.OldReviewerID,
+		NewReviewerID: replacement,
+		Automatic:     input.Automatic,
+		At:            now,
+	})
 
 	return &ReassignResult{PR: clonePullRequest(pr), ReplacedBy: replacement}, nil
 }
 
-func (s *Store) pickReplacementCandidatesLocked(team *teamRecord, authorID string, assigned []string, skip string) []string {
-	assignedSet := make(map[string]struct{}, len(assigned))
-	for _, id := range assigned {
-		if id != skip {
-			assignedSet[id] = struct{}{}
+func choosePreferred(candidates, preferred []string) string {
+	eligible := make(map[string]struct{}, len(candidates))
+	for _, id := range candidates {
+		eligible[id] = struct{}{}
+	}
+	for _, id := range preferred {
+		if _, ok := eligible[id]; ok {
+			return id
 		}
 	}
+	return ""
+}
 
-	candidates := make([]string, 0, len(team.Members))
-	for memberID := range team.Members {
-		if memberID == skip || memberID == authorID {
-			continue
-		}
-		if _, exists := assignedSet[memberID]; exists {
-			continue
-		}
-		user := s.users[memberID]
-		if user == nil || !user.IsActive {
-			continue
+func (s *Store) AddReviewer(prID, userID string) (*PullRequest, error) {
+	return s.AddReviewerIf(prID, userID, AnyVersion)
+}
+
+// AddReviewerIf is AddReviewer that fails with ErrVersionMismatch unless the
+// pull request is at version.
+func (s *Store) AddReviewerIf(prID, userID string, version uint64) (*PullRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr, err := s.pullRequestLocked(prID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkOpen(pr); err != nil {
+		return nil, err
+	}
+
+	user, ok := s.users[userID]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	if user.ID == pr.AuthorID {
+		return nil, ErrReviewerIsAuthor
+	}
+	if !user.IsActive {
+		return nil, ErrReviewerInactive
+	}
+	if reviewerIndex(pr.AssignedReviewers, user.ID) != -1 {
+		return nil, ErrReviewerAlreadyAssigned
+	}
+	if s.atCapacityLocked(s.openReviewsLocked(), user.ID) {
+		return nil, ErrReviewerAtCapacity
+	}
+
+	// The pool the reviewer was picked from is their team, as it would be
+	// for an automatic assignment; a reviewer without a team is the only
+	// candidate.
+	candidates, excluded := []string{user.ID}, []CandidateExclusion{}
+	if team, ok := s.teams[user.TeamName]; ok {
+		candidates, excluded = s.evaluateCandidatesLocked(team, pr.AuthorID, pr.AssignedReviewers, "")
+	}
+
+	now := s.nowUTC()
+	pr.AssignedReviewers = append(pr.AssignedReviewers, user.ID)
+	pr.Assignments = append(pr.Assignments, ReviewerAssignment{UserID: user.ID, AssignedAt: now})
+	pr.Version++
+	s.recordExplanationLocked(pr.ID, AssignmentExplanation{
+		Kind:       AssignmentManual,
+		Strategy:   StrategyManual,
+		At:         now,
+		Candidates: candidates,
+		Excluded:   excluded,
+		Selected:   []string{user.ID},
+		TeamName:   user.TeamName,
+	})
+	s.emitLocked(ReviewerAdded{Org: s.org, PR: clonePullRequest(pr), TeamName: s.authorTeamNameLocked(pr), ReviewerID: user.ID, At: now})
+	return clonePullRequest(pr), nil
+}
+
+func (s *Store) RemoveReviewer(prID, userID string) (*PullRequest, error) {
+	return s.RemoveReviewerIf(prID, userID, AnyVersion)
+}
+
+// RemoveReviewerIf is RemoveReviewer that fails with ErrVersionMismatch unless the
+// pull request is at version.
+func (s *Store) RemoveReviewerIf(prID, userID string, version uint64) (*PullRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr, err := s.pullRequestLocked(prID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkOpen(pr); err != nil {
+		return nil, err
+	}
+
+	index := reviewerIndex(pr.AssignedReviewers, userID)
+	if index == -1 {
+		return nil, ErrReviewerNotAssigned
+	}
+
+	pr.AssignedReviewers = append(pr.AssignedReviewers[:index], pr.AssignedReviewers[index+1:]...)
+	pr.Assignments = append(pr.Assignments[:index], pr.Assignments[index+1:]...)
+	pr.Version++
+	s.emitLocked(ReviewerRemoved{Org: s.org, PR: clonePullRequest(pr), TeamName: s.authorTeamNameLocked(pr), ReviewerID: userID, At: s.nowUTC()})
+	return clonePullRequest(pr), nil
+}
+
+func reviewerIndex(reviewers []string, userID string) int {
+	for i, reviewer := range reviewers {
+		if reviewer == userID {
+			return i
 		}
-		candidates = append(candidates, memberID)
 	}
-	return candidates
+	return -1
 }
 
 func (s *Store) ListPullRequestsByReviewer(userID string) ([]*PullRequest, error) {
@@ -388,8 +825,11 @@ func (s *Store) buildTeamLocked(record *teamRecord) *Team {
 	})
 
 	return &Team{
-		Name:    record.Name,
-		Members: members,
+		Name:      record.Name,
+		LeadID:    record.LeadID,
+		Members:   members,
+		ReviewSLA: record.ReviewSLA,
+		Version:   record.Version,
 	}
 }
 
@@ -410,9 +850,19 @@ func clonePullRequest(pr *PullRequest) *PullRequest {
 		clone.AssignedReviewers = make([]string, len(pr.AssignedReviewers))
 		copy(clone.AssignedReviewers, pr.AssignedReviewers)
 	}
+	if pr.Assignments != nil {
+		clone.Assignments = make([]ReviewerAssignment, len(pr.Assignments))
+		for i, a := range pr.Assignments {
+			clone.Assignments[i] = cloneAssignment(a)
+		}
+	}
 	if pr.MergedAt != nil {
 		ts := *pr.MergedAt
 		clone.MergedAt = &ts
 	}
+	if pr.ClosedAt != nil {
+		ts := *pr.ClosedAt
+		clone.ClosedAt = &ts
+	}
 	return &clone
 }
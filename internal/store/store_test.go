@@ -0,0 +1,309 @@
+package store
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+var testStart = time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newTestClock() *testClock {
+	return &testClock{now: testStart}
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// mustCreateTeam creates a team of active members named after their IDs.
+func mustCreateTeam(t *testing.T, s *Store, name string, userIDs ...string) *Team {
+	t.Helper()
+	members := make([]TeamMemberInput, 0, len(userIDs))
+	for _, id := range userIDs {
+		members = append(members, TeamMemberInput{UserID: id, Username: id, IsActive: true})
+	}
+	team, err := s.CreateTeam(name, members)
+	if err != nil {
+		t.Fatalf("CreateTeam(%s): %v", name, err)
+	}
+	return team
+}
+
+func mustCreatePR(t *testing.T, s *Store, id, authorID string) *PullRequest {
+	t.Helper()
+	pr, err := s.CreatePullRequest(CreatePullRequestInput{ID: id, Name: id, AuthorID: authorID})
+	if err != nil {
+		t.Fatalf("CreatePullRequest(%s): %v", id, err)
+	}
+	return pr
+}
+
+func firstReviewer(t *testing.T, s *Store, prID string) string {
+	t.Helper()
+	pr, err := s.GetPullRequest(prID)
+	if err != nil {
+		t.Fatalf("GetPullRequest(%s): %v", prID, err)
+	}
+	if len(pr.AssignedReviewers) == 0 {
+		t.Fatalf("%s has no reviewers", prID)
+	}
+	return pr.AssignedReviewers[0]
+}
+
+func TestSetUserActiveIf(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     func(team *Team) uint64
+		isActive    bool
+		wantErr     error
+		wantVersion func(team *Team) uint64
+	}{
+		{"any version", func(*Team) uint64 { return AnyVersion }, false, nil, func(team *Team) uint64 { return team.Version + 1 }},
+		{"current version", func(team *Team) uint64 { return team.Version }, false, nil, func(team *Team) uint64 { return team.Version + 1 }},
+		{"stale version", func(team *Team) uint64 { return team.Version + 1 }, false, ErrVersionMismatch, func(team *Team) uint64 { return team.Version }},
+		{"no change keeps the version", func(team *Team) uint64 { return team.Version }, true, nil, func(team *Team) uint64 { return team.Version }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			team := mustCreateTeam(t, s, "backend", "u1", "u2")
+
+			user, teamVersion, err := s.SetUserActiveIf("u2", tt.isActive, tt.version(team))
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && user.IsActive != tt.isActive {
+				t.Errorf("IsActive = %v, want %v", user.IsActive, tt.isActive)
+			}
+			after, _ := s.GetTeam("backend")
+			if want := tt.wantVersion(team); after.Version != want {
+				t.Errorf("team version = %d, want %d", after.Version, want)
+			}
+			if err == nil && teamVersion != after.Version {
+				t.Errorf("returned team version = %d, want %d", teamVersion, after.Version)
+			}
+			if err != nil {
+				if got, _ := s.GetUser("u2"); !got.IsActive {
+					t.Error("rejected change was applied")
+				}
+			}
+		})
+	}
+
+	if _, _, err := New().SetUserActiveIf("missing", true, AnyVersion); err != ErrUserNotFound {
+		t.Errorf("unknown user: err = %v, want %v", err, ErrUserNotFound)
+	}
+}
+
+// reviewerFixture opens pr-1 by u1 in a team where u5 is inactive and returns
+// it with the one active member left unassigned.
+func reviewerFixture(t *testing.T) (*Store, *PullRequest, string) {
+	t.Helper()
+	s := New(WithSelectionMode(SelectionDeterministic))
+	mustCreateTeam(t, s, "backend", "u1", "u2", "u3", "u4", "u5")
+	if _, err := s.SetUserActive("u5", false); err != nil {
+		t.Fatalf("SetUserActive: %v", err)
+	}
+	pr := mustCreatePR(t, s, "pr-1", "u1")
+	for _, id := range []string{"u2", "u3", "u4"} {
+		if reviewerIndex(pr.AssignedReviewers, id) == -1 {
+			return s, pr, id
+		}
+	}
+	t.Fatalf("pr-1 reviewers = %v, want two of u2, u3, u4", pr.AssignedReviewers)
+	return nil, nil, ""
+}
+
+func TestAddReviewerIf(t *testing.T) {
+	tests := []struct {
+		name    string
+		merge   bool
+		user    func(pr *PullRequest, idle string) string
+		version func(pr *PullRequest) uint64
+		wantErr error
+	}{
+		{"idle member", false, func(_ *PullRequest, idle string) string { return idle }, func(pr *PullRequest) uint64 { return pr.Version }, nil},
+		{"any version", false, func(_ *PullRequest, idle string) string { return idle }, func(*PullRequest) uint64 { return AnyVersion }, nil},
+		{"stale version", false, func(_ *PullRequest, idle string) string { return idle }, func(pr *PullRequest) uint64 { return pr.Version + 1 }, ErrVersionMismatch},
+		{"merged", true, func(_ *PullRequest, idle string) string { return idle }, func(*PullRequest) uint64 { return AnyVersion }, ErrPullRequestMerged},
+		{"author", false, func(*PullRequest, string) string { return "u1" }, func(*PullRequest) uint64 { return AnyVersion }, ErrReviewerIsAuthor},
+		{"inactive", false, func(*PullRequest, string) string { return "u5" }, func(*PullRequest) uint64 { return AnyVersion }, ErrReviewerInactive},
+		{"already assigned", false, func(pr *PullRequest, _ string) string { return pr.AssignedReviewers[0] }, func(*PullRequest) uint64 { return AnyVersion }, ErrReviewerAlreadyAssigned},
+		{"unknown user", false, func(*PullRequest, string) string { return "missing" }, func(*PullRequest) uint64 { return AnyVersion }, ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, pr, idle := reviewerFixture(t)
+			if tt.merge {
+				var err error
+				if pr, err = s.MergePullRequest("pr-1"); err != nil {
+					t.Fatalf("MergePullRequest: %v", err)
+				}
+			}
+			userID := tt.user(pr, idle)
+
+			got, err := s.AddReviewerIf("pr-1", userID, tt.version(pr))
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			after, _ := s.GetPullRequest("pr-1")
+			if err != nil {
+				if after.Version != pr.Version || len(after.AssignedReviewers) != len(pr.AssignedReviewers) {
+					t.Errorf("rejected add changed pr-1 to version %d with %v", after.Version, after.AssignedReviewers)
+				}
+				return
+			}
+			if got.Version != pr.Version+1 || after.Version != got.Version {
+				t.Errorf("version = %d (stored %d), want %d", got.Version, after.Version, pr.Version+1)
+			}
+			if want := append(append([]string(nil), pr.AssignedReviewers...), userID); len(after.AssignedReviewers) != len(want) || after.AssignedReviewers[len(want)-1] != userID {
+				t.Errorf("reviewers = %v, want %v", after.AssignedReviewers, want)
+			}
+		})
+	}
+
+	if _, err := New().AddReviewer("missing", "u1"); err != ErrPullRequestNotFound {
+		t.Errorf("unknown pull request: err = %v, want %v", err, ErrPullRequestNotFound)
+	}
+}
+
+func TestRemoveReviewerIf(t *testing.T) {
+	tests := []struct {
+		name    string
+		merge   bool
+		user    func(pr *PullRequest, idle string) string
+		version func(pr *PullRequest) uint64
+		wantErr error
+	}{
+		{"assigned reviewer", false, func(pr *PullRequest, _ string) string { return pr.AssignedReviewers[0] }, func(pr *PullRequest) uint64 { return pr.Version }, nil},
+		{"any version", false, func(pr *PullRequest, _ string) string { return pr.AssignedReviewers[1] }, func(*PullRequest) uint64 { return AnyVersion }, nil},
+		{"stale version", false, func(pr *PullRequest, _ string) string { return pr.AssignedReviewers[0] }, func(pr *PullRequest) uint64 { return pr.Version + 1 }, ErrVersionMismatch},
+		{"merged", true, func(pr *PullRequest, _ string) string { return pr.AssignedReviewers[0] }, func(*PullRequest) uint64 { return AnyVersion }, ErrPullRequestMerged},
+		{"not assigned", false, func(_ *PullRequest, idle string) string { return idle }, func(*PullRequest) uint64 { return AnyVersion }, ErrReviewerNotAssigned},
+		{"author", false, func(*PullRequest, string) string { return "u1" }, func(*PullRequest) uint64 { return AnyVersion }, ErrReviewerNotAssigned},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, pr, idle := reviewerFixture(t)
+			if tt.merge {
+				var err error
+				if pr, err = s.MergePullRequest("pr-1"); err != nil {
+					t.Fatalf("MergePullRequest: %v", err)
+				}
+			}
+			userID := tt.user(pr, idle)
+
+			got, err := s.RemoveReviewerIf("pr-1", userID, tt.version(pr))
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			after, _ := s.GetPullRequest("pr-1")
+			if err != nil {
+				if after.Version != pr.Version || len(after.AssignedReviewers) != len(pr.AssignedReviewers) {
+					t.Errorf("rejected removal changed pr-1 to version %d with %v", after.Version, after.AssignedReviewers)
+				}
+				return
+			}
+			if got.Version != pr.Version+1 || after.Version != got.Version {
+				t.Errorf("version = %d (stored %d), want %d", got.Version, after.Version, pr.Version+1)
+			}
+			if reviewerIndex(after.AssignedReviewers, userID) != -1 || len(after.AssignedReviewers) != len(pr.AssignedReviewers)-1 {
+				t.Errorf("reviewers = %v, want %v without %s", after.AssignedReviewers, pr.AssignedReviewers, userID)
+			}
+			if len(after.Assignments) != len(after.AssignedReviewers) {
+				t.Errorf("assignments = %v, want one per reviewer %v", after.Assignments, after.AssignedReviewers)
+			}
+		})
+	}
+}
+
+func TestReassignPreferred(t *testing.T) {
+	// r is the reviewer being replaced, k the one kept, and i0 and i1 the
+	// idle members who can take over.
+	type fixture struct{ r, k, i0, i1 string }
+
+	tests := []struct {
+		name         string
+		preferred    func(f fixture) []string
+		fallback     bool
+		wantErr      error
+		want         func(f fixture) []string
+		wantStrategy string
+	}{
+		{"first preferred", func(f fixture) []string { return []string{f.i1, f.i0} },
+			false, nil, func(f fixture) []string { return []string{f.i1} }, StrategyPreferred},
+		{"skips ineligible", func(f fixture) []string { return []string{"u1", f.r, f.k, "u6", "f1", "missing", f.i0} },
+			false, nil, func(f fixture) []string { return []string{f.i0} }, StrategyPreferred},
+		{"none eligible", func(f fixture) []string { return []string{"u1", f.k, "u6"} },
+			false, ErrPreferredReviewerIneligible, nil, ""},
+		{"falls back to random", func(f fixture) []string { return []string{"u1", f.k, "u6"} },
+			true, nil, func(f fixture) []string { return []string{f.i0, f.i1} }, StrategyPreferredFallback},
+		{"fallback prefers an eligible user", func(f fixture) []string { return []string{"u6", f.i1} },
+			true, nil, func(f fixture) []string { return []string{f.i1} }, StrategyPreferred},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			mustCreateTeam(t, s, "backend", "u1", "u2", "u3", "u4", "u5", "u6")
+			mustCreateTeam(t, s, "frontend", "f1")
+			if _, err := s.SetUserActive("u6", false); err != nil {
+				t.Fatalf("SetUserActive: %v", err)
+			}
+			pr := mustCreatePR(t, s, "pr-1", "u1")
+			f := fixture{r: pr.AssignedReviewers[0], k: pr.AssignedReviewers[1]}
+			for _, id := range []string{"u2", "u3", "u4", "u5"} {
+				if reviewerIndex(pr.AssignedReviewers, id) != -1 {
+					continue
+				}
+				if f.i0 == "" {
+					f.i0 = id
+				} else {
+					f.i1 = id
+				}
+			}
+
+			result, err := s.ReassignReviewer(ReassignReviewerInput{
+				PullRequestID:  "pr-1",
+				OldReviewerID:  f.r,
+				Preferred:      tt.preferred(f),
+				FallbackToAuto: tt.fallback,
+			})
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if after, _ := s.GetPullRequest("pr-1"); after.Version != pr.Version {
+					t.Errorf("rejected reassignment changed the version to %d", after.Version)
+				}
+				return
+			}
+			if reviewerIndex(tt.want(f), result.ReplacedBy) == -1 {
+				t.Errorf("replaced by %s, want one of %v", result.ReplacedBy, tt.want(f))
+			}
+			history, _ := s.ExplainAssignments("pr-1")
+			if got := history[len(history)-1].Strategy; got != tt.wantStrategy {
+				t.Errorf("strategy = %s, want %s", got, tt.wantStrategy)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package store
+
+// Transaction runs fn against a private copy of the store while holding the
+// store's write lock, so other callers wait until it finishes. If fn returns
+// nil, the copy replaces the store's data and the events it collected are
+// emitted in order; otherwise all changes are discarded and fn's error is
+// returned. tx must not be used after fn returns, and fn must not call back
+// into s.
+func (s *Store) Transaction(fn func(tx *Store) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := s.copyLocked()
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	s.teams = tx.teams
+	s.users = tx.users
+	s.prs = tx.prs
+	s.explanations = tx.explanations
+	for _, e := range tx.pending {
+		s.emitLocked(e)
+	}
+	return nil
+}
+
+// copyLocked returns a deep copy of the data that buffers its events instead
+// of delivering them.
+func (s *Store) copyLocked() *Store {
+	tx := &Store{
+		org:   s.org,
+		teams: make(map[string]*teamRecord, len(s.teams)),
+		users: make(map[string]*User, len(s.users)),
+		prs:   make(map[string]*PullRequest, len(s.prs)),
+		rnd:   s.rnd,
+
+		now:       s.now,
+		seed:      s.seed,
+		selection: s.selection,
+		reviewSLA: s.reviewSLA,
+
+		maxOpenReviews: s.maxOpenReviews,
+
+		explanations: make(map[string][]AssignmentExplanation, len(s.explanations)),
+
+		buffered: true,
+	}
+
+	for name, record := range s.teams {
+		clone := *record
+		clone.Members = make(map[string]struct{}, len(record.Members))
+		for id := range record.Members {
+			clone.Members[id] = struct{}{}
+		}
+		tx.teams[name] = &clone
+	}
+	for id, user := range s.users {
+		tx.users[id] = cloneUser(user)
+	}
+	for id, pr := range s.prs {
+		tx.prs[id] = clonePullRequest(pr)
+	}
+	for id, history := range s.explanations {
+		clones := make([]AssignmentExplanation, 0, len(history))
+		for _, e := range history {
+			clones = append(clones, cloneExplanation(e))
+		}
+		tx.explanations[id] = clones
+	}
+	return tx
+}
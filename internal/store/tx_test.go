@@ -0,0 +1,166 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+var errAbort = errors.New("abort")
+
+// eventNames subscribes to s and returns a function that waits for the next
+// n event names.
+func eventNames(t *testing.T, s *Store) func(n int) []string {
+	t.Helper()
+	ch := make(chan string, 64)
+	t.Cleanup(s.Subscribe(func(e Event) { ch <- e.Name() }))
+	return func(n int) []string {
+		t.Helper()
+		names := make([]string, 0, n)
+		for len(names) < n {
+			select {
+			case name := <-ch:
+				names = append(names, name)
+			case <-time.After(time.Second):
+				t.Fatalf("got events %v, want %d", names, n)
+			}
+		}
+		return names
+	}
+}
+
+// snapshot describes everything a transaction may touch in the fixture.
+func snapshot(t *testing.T, s *Store) string {
+	t.Helper()
+	var b strings.Builder
+	for _, name := range []string{"backend", "frontend"} {
+		team, err := s.GetTeam(name)
+		if err != nil {
+			fmt.Fprintf(&b, "team %s: %v\n", name, err)
+			continue
+		}
+		fmt.Fprintf(&b, "team %s v%d lead=%s members=%v\n", name, team.Version, team.LeadID, team.Members)
+	}
+	for _, id := range []string{"pr-1", "pr-2"} {
+		pr, err := s.GetPullRequest(id)
+		if err != nil {
+			fmt.Fprintf(&b, "%s: %v\n", id, err)
+			continue
+		}
+		fmt.Fprintf(&b, "%s v%d %s %v\n", id, pr.Version, pr.Status, pr.AssignedReviewers)
+		history, _ := s.ExplainAssignments(id)
+		fmt.Fprintf(&b, "%s explanations=%d\n", id, len(history))
+	}
+	return b.String()
+}
+
+func TestTransactionRollsBack(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(tx *Store) error
+	}{
+		{"new team", func(tx *Store) error {
+			_, err := tx.CreateTeam("frontend", []TeamMemberInput{{UserID: "u9", Username: "u9", IsActive: true}})
+			return err
+		}},
+		{"new pull request", func(tx *Store) error {
+			_, err := tx.CreatePullRequest(CreatePullRequestInput{ID: "pr-2", Name: "pr-2", AuthorID: "u2"})
+			return err
+		}},
+		{"existing team and user", func(tx *Store) error {
+			if _, err := tx.SetTeamLead("backend", "u2"); err != nil {
+				return err
+			}
+			_, err := tx.SetUserActive("u3", false)
+			return err
+		}},
+		{"existing pull request", func(tx *Store) error {
+			pr, err := tx.GetPullRequest("pr-1")
+			if err != nil {
+				return err
+			}
+			if _, err := tx.ReassignReviewer(ReassignReviewerInput{PullRequestID: "pr-1", OldReviewerID: pr.AssignedReviewers[0]}); err != nil {
+				return err
+			}
+			if _, err := tx.SubmitReview("pr-1", pr.AssignedReviewers[1]); err != nil {
+				return err
+			}
+			_, err = tx.MergePullRequest("pr-1")
+			return err
+		}},
+		{"everything", func(tx *Store) error {
+			if _, err := tx.CreateTeam("frontend", []TeamMemberInput{{UserID: "u9", Username: "u9", IsActive: true}}); err != nil {
+				return err
+			}
+			if _, err := tx.CreatePullRequest(CreatePullRequestInput{ID: "pr-2", Name: "pr-2", AuthorID: "u2"}); err != nil {
+				return err
+			}
+			_, err := tx.MergePullRequest("pr-1")
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(WithSelectionMode(SelectionDeterministic))
+			mustCreateTeam(t, s, "backend", "u1", "u2", "u3", "u4")
+			mustCreatePR(t, s, "pr-1", "u1")
+			next := eventNames(t, s)
+			before := snapshot(t, s)
+
+			err := s.Transaction(func(tx *Store) error {
+				if err := tt.fn(tx); err != nil {
+					t.Fatalf("operation: %v", err)
+				}
+				if snapshot(t, tx) == before {
+					t.Fatal("operation changed nothing")
+				}
+				return errAbort
+			})
+			if err != errAbort {
+				t.Fatalf("Transaction() = %v, want %v", err, errAbort)
+			}
+			if after := snapshot(t, s); after != before {
+				t.Errorf("store changed:\nbefore %s\nafter  %s", before, after)
+			}
+
+			// No event of the discarded changes is delivered before this one.
+			mustCreatePR(t, s, "pr-3", "u1")
+			if got := next(1); got[0] != "pull_request.created" {
+				t.Errorf("first event after rollback = %v, want pull_request.created", got)
+			}
+		})
+	}
+}
+
+func TestTransactionCommits(t *testing.T) {
+	s := New(WithSelectionMode(SelectionDeterministic))
+	mustCreateTeam(t, s, "backend", "u1", "u2", "u3", "u4")
+	next := eventNames(t, s)
+
+	var inside string
+	err := s.Transaction(func(tx *Store) error {
+		if _, err := tx.CreatePullRequest(CreatePullRequestInput{ID: "pr-1", Name: "pr-1", AuthorID: "u1"}); err != nil {
+			return err
+		}
+		if _, err := tx.SetUserActive("u4", false); err != nil {
+			return err
+		}
+		if _, err := tx.MergePullRequest("pr-1"); err != nil {
+			return err
+		}
+		inside = snapshot(t, tx)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction: %v", err)
+	}
+	if after := snapshot(t, s); after != inside {
+		t.Errorf("committed state:\n%s\nwant\n%s", after, inside)
+	}
+	want := "[pull_request.created user.activity_changed pull_request.merged]"
+	if got := fmt.Sprint(next(3)); got != want {
+		t.Errorf("events = %s, want %s", got, want)
+	}
+}
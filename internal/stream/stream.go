@@ -0,0 +1,170 @@
+package stream
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	EventAssignment   = "assignment"
+	EventReassignment = "reassignment"
+	EventReview       = "review"
+	EventMerge        = "merge"
+)
+
+type Event struct {
+	ID            uint64
+	Type          string
+	Org           string
+	PullRequestID string
+	TeamName      string
+	Users         []string
+	At            time.Time
+	Data          any
+}
+
+// Filter selects events for a subscriber. Org must always match; other empty
+// fields match everything.
+type Filter struct {
+	Org      string
+	UserID   string
+	TeamName string
+}
+
+func (f Filter) Match(e Event) bool {
+	if e.Org != f.Org {
+		return false
+	}
+	if f.TeamName != "" && e.TeamName != f.TeamName {
+		return false
+	}
+	if f.UserID == "" {
+		return true
+	}
+	for _, id := range e.Users {
+		if id == f.UserID {
+			return true
+		}
+	}
+	return false
+}
+
+type Config struct {
+	BufferSize     int
+	SubscriberSize int
+	Now            func() time.Time
+}
+
+// Broker fans events out to live subscribers and keeps the most recent ones
+// so reconnecting clients can resume after the last ID they saw.
+type Broker struct {
+	cfg Config
+
+	mu     sync.Mutex
+	nextID uint64
+	buffer []Event
+	subs   map[*subscriber]struct{}
+	closed bool
+}
+
+type subscriber struct {
+	filter Filter
+	ch     chan Event
+}
+
+func New(cfg Config) *Broker {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 1000
+	}
+	if cfg.SubscriberSize <= 0 {
+		cfg.SubscriberSize = 64
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Broker{
+		cfg:    cfg,
+		nextID: 1,
+		subs:   make(map[*subscriber]struct{}),
+	}
+}
+
+func (b *Broker) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	e.ID = b.nextID
+	b.nextID++
+	if e.At.IsZero() {
+		e.At = b.cfg.Now().UTC()
+	}
+
+	b.buffer = append(b.buffer, e)
+	if over := len(b.buffer) - b.cfg.BufferSize; over > 0 {
+		b.buffer = append(b.buffer[:0], b.buffer[over:]...)
+	}
+
+	for sub := range b.subs {
+		if !sub.filter.Match(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			// A subscriber that cannot keep up is disconnected; it can
+			// resume from its last event ID.
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Subscribe returns buffered events after lastID that match filter and a
+// channel of new ones. The channel is closed when the subscriber falls behind
+// or the broker is closed; cancel releases it early.
+func (b *Broker) Subscribe(filter Filter, lastID uint64) ([]Event, <-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	backlog := make([]Event, 0)
+	if lastID > 0 {
+		for _, e := range b.buffer {
+			if e.ID > lastID && filter.Match(e) {
+				backlog = append(backlog, e)
+			}
+		}
+	}
+
+	sub := &subscriber{filter: filter, ch: make(chan Event, b.cfg.SubscriberSize)}
+	if b.closed {
+		close(sub.ch)
+		return backlog, sub.ch, func() {}
+	}
+	b.subs[sub] = struct{}{}
+
+	cancel := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[sub]; ok {
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+	return backlog, sub.ch, cancel
+}
+
+// Close disconnects every subscriber and drops later events.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
@@ -0,0 +1,187 @@
+package stream
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ids(events []Event) string {
+	out := make([]uint64, 0, len(events))
+	for _, e := range events {
+		out = append(out, e.ID)
+	}
+	return fmt.Sprint(out)
+}
+
+// drain reads what is queued on ch and reports whether ch was closed.
+func drain(ch <-chan Event) ([]Event, bool) {
+	var got []Event
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				return got, true
+			}
+			got = append(got, e)
+		default:
+			return got, false
+		}
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	e := Event{Org: "acme", TeamName: "backend", Users: []string{"u1", "u2"}}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"organization only", Filter{Org: "acme"}, true},
+		{"other organization", Filter{Org: "globex"}, false},
+		{"no organization", Filter{}, false},
+		{"team", Filter{Org: "acme", TeamName: "backend"}, true},
+		{"other team", Filter{Org: "acme", TeamName: "frontend"}, false},
+		{"involved user", Filter{Org: "acme", UserID: "u2"}, true},
+		{"uninvolved user", Filter{Org: "acme", UserID: "u3"}, false},
+		{"team and user", Filter{Org: "acme", TeamName: "backend", UserID: "u1"}, true},
+		{"same user in another organization", Filter{Org: "globex", UserID: "u1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(e); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublishAssignsIDsAndTime(t *testing.T) {
+	at := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	b := New(Config{Now: func() time.Time { return at }})
+	_, ch, cancel := b.Subscribe(Filter{Org: "acme"}, 0)
+	defer cancel()
+
+	b.Publish(Event{Org: "acme"})
+	b.Publish(Event{Org: "acme", At: at.Add(time.Hour)})
+
+	got, _ := drain(ch)
+	if ids(got) != "[1 2]" {
+		t.Fatalf("ids = %s, want [1 2]", ids(got))
+	}
+	if !got[0].At.Equal(at) || !got[1].At.Equal(at.Add(time.Hour)) {
+		t.Errorf("times = %s, %s; want the clock for a zero time and the given time otherwise", got[0].At, got[1].At)
+	}
+}
+
+func TestSubscribeScopesToOrg(t *testing.T) {
+	b := New(Config{})
+	_, acme, cancelAcme := b.Subscribe(Filter{Org: "acme"}, 0)
+	defer cancelAcme()
+	_, globex, cancelGlobex := b.Subscribe(Filter{Org: "globex"}, 0)
+	defer cancelGlobex()
+
+	b.Publish(Event{Org: "acme", Users: []string{"u1"}})
+	b.Publish(Event{Org: "globex", Users: []string{"u1"}})
+	b.Publish(Event{Org: "acme", Users: []string{"u1"}})
+
+	if got, _ := drain(acme); ids(got) != "[1 3]" {
+		t.Errorf("acme received %s, want [1 3]", ids(got))
+	}
+	if got, _ := drain(globex); ids(got) != "[2]" {
+		t.Errorf("globex received %s, want [2]", ids(got))
+	}
+
+	backlog, _, cancel := b.Subscribe(Filter{Org: "globex"}, 1)
+	defer cancel()
+	if ids(backlog) != "[2]" {
+		t.Errorf("globex backlog = %s, want [2]", ids(backlog))
+	}
+}
+
+func TestSubscribeResumesAfterLastID(t *testing.T) {
+	tests := []struct {
+		lastID uint64
+		want   string
+	}{
+		{0, "[]"},
+		{1, "[3 4 5]"},
+		{3, "[4 5]"},
+		{5, "[]"},
+		{9, "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.lastID), func(t *testing.T) {
+			b := New(Config{BufferSize: 3})
+			for i := 0; i < 5; i++ {
+				b.Publish(Event{Org: "acme"})
+			}
+
+			backlog, ch, cancel := b.Subscribe(Filter{Org: "acme"}, tt.lastID)
+			defer cancel()
+			if ids(backlog) != tt.want {
+				t.Errorf("backlog = %s, want %s", ids(backlog), tt.want)
+			}
+
+			b.Publish(Event{Org: "acme"})
+			if got, _ := drain(ch); len(got) != 1 {
+				t.Errorf("live events = %s, want the one published after subscribing", ids(got))
+			}
+		})
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	b := New(Config{SubscriberSize: 2})
+	_, slow, cancelSlow := b.Subscribe(Filter{Org: "acme"}, 0)
+	defer cancelSlow()
+	_, fast, cancelFast := b.Subscribe(Filter{Org: "acme"}, 0)
+	defer cancelFast()
+
+	var received []Event
+	for i := 0; i < 3; i++ {
+		b.Publish(Event{Org: "acme"})
+		got, _ := drain(fast)
+		received = append(received, got...)
+	}
+
+	got, closed := drain(slow)
+	if ids(got) != "[1 2]" || !closed {
+		t.Errorf("slow subscriber got %s, closed %v; want [1 2] and closed", ids(got), closed)
+	}
+	if ids(received) != "[1 2 3]" {
+		t.Errorf("fast subscriber got %s, want [1 2 3]", ids(received))
+	}
+
+	// The dropped subscriber resumes from the last event it saw.
+	backlog, _, cancel := b.Subscribe(Filter{Org: "acme"}, got[len(got)-1].ID)
+	defer cancel()
+	if ids(backlog) != "[3]" {
+		t.Errorf("backlog after reconnecting = %s, want [3]", ids(backlog))
+	}
+}
+
+func TestCancelAndClose(t *testing.T) {
+	b := New(Config{})
+	_, cancelled, cancel := b.Subscribe(Filter{Org: "acme"}, 0)
+	cancel()
+	cancel()
+	if _, closed := drain(cancelled); !closed {
+		t.Errorf("channel is open after cancel")
+	}
+
+	_, live, cancelLive := b.Subscribe(Filter{Org: "acme"}, 0)
+	defer cancelLive()
+	b.Close()
+	if _, closed := drain(live); !closed {
+		t.Errorf("channel is open after Close")
+	}
+
+	b.Publish(Event{Org: "acme"})
+	backlog, late, cancelLate := b.Subscribe(Filter{Org: "acme"}, 0)
+	defer cancelLate()
+	if _, closed := drain(late); !closed || len(backlog) != 0 {
+		t.Errorf("subscribe after Close: backlog %s, closed %v; want none and closed", ids(backlog), closed)
+	}
+}
@@ -0,0 +1,423 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ToxicSozo/GoDraw/internal/store"
+)
+
+const (
+	EventReviewerAssigned   = "reviewer.assigned"
+	EventReviewerReassigned = "reviewer.reassigned"
+	EventPullRequestMerged  = "pull_request.merged"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+var (
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrInvalidURL           = errors.New("invalid webhook url")
+	ErrUnknownEvent         = errors.New("unknown webhook event")
+)
+
+var knownEvents = map[string]struct{}{
+	EventReviewerAssigned:   {},
+	EventReviewerReassigned: {},
+	EventPullRequestMerged:  {},
+}
+
+type Subscription struct {
+	ID        string
+	Org       string
+	URL       string
+	Events    []string
+	Secret    string
+	CreatedAt time.Time
+}
+
+type Delivery struct {
+	ID             string
+	Org            string
+	SubscriptionID string
+	Event          string
+	Attempt        int
+	StatusCode     int
+	Error          string
+	Success        bool
+	At             time.Time
+}
+
+type Config struct {
+	Client      *http.Client
+	Workers     int
+	QueueSize   int
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	LogSize     int
+	Now         func() time.Time
+}
+
+type Dispatcher struct {
+	cfg   Config
+	queue chan job
+
+	mu         sync.RWMutex
+	subs       map[string]*Subscription
+	deliveries []Delivery
+}
+
+type job struct {
+	id        string
+	sub       Subscription
+	event     string
+	body      []byte
+	signature string
+	attempt   int
+}
+
+type envelope struct {
+	ID         string `json:"id"`
+	Event      string `json:"event"`
+	OccurredAt string `json:"occurred_at"`
+	Data       any    `json:"data"`
+}
+
+func New(cfg Config) *Dispatcher {
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 5 * time.Second}
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 256
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Minute
+	}
+	if cfg.LogSize <= 0 {
+		cfg.LogSize = 500
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Dispatcher{
+		cfg:   cfg,
+		queue: make(chan job, cfg.QueueSize),
+		subs:  make(map[string]*Subscription),
+	}
+}
+
+// Subscribe registers a subscription in org. Subscriptions only receive
+// events published for their own organization.
+func (d *Dispatcher) Subscribe(org, rawURL string, events []string, secret string) (*Subscription, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidURL
+	}
+
+	unique := make(map[string]struct{}, len(events))
+	for _, event := range events {
+		if _, ok := knownEvents[event]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownEvent, event)
+		}
+		unique[event] = struct{}{}
+	}
+	if len(unique) == 0 {
+		for event := range knownEvents {
+			unique[event] = struct{}{}
+		}
+	}
+
+	sub := &Subscription{
+		ID:        newID(),
+		Org:       org,
+		URL:       rawURL,
+		Events:    make([]string, 0, len(unique)),
+		Secret:    secret,
+		CreatedAt: d.cfg.Now().UTC(),
+	}
+	for event := range unique {
+		sub.Events = append(sub.Events, event)
+	}
+	sort.Strings(sub.Events)
+
+	d.mu.Lock()
+	d.subs[sub.ID] = sub
+	d.mu.Unlock()
+
+	clone := *sub
+	return &clone, nil
+}
+
+func (d *Dispatcher) Unsubscribe(org, id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if sub, ok := d.subs[id]; !ok || sub.Org != org {
+		return ErrSubscriptionNotFound
+	}
+	delete(d.subs, id)
+	return nil
+}
+
+func (d *Dispatcher) Subscriptions(org string) []Subscription {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	result := make([]Subscription, 0, len(d.subs))
+	for _, sub := range d.subs {
+		if sub.Org != org {
+			continue
+		}
+		clone := *sub
+		clone.Events = append([]string(nil), sub.Events...)
+		result = append(result, clone)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt) ||
+			(result[i].CreatedAt.Equal(result[j].CreatedAt) && result[i].ID < result[j].ID)
+	})
+	return result
+}
+
+// Deliveries returns logged delivery attempts in org, newest first. An empty
+// subscriptionID returns attempts for every subscription of org.
+func (d *Dispatcher) Deliveries(org, subscriptionID string) ([]Delivery, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if subscriptionID != "" {
+		if sub, ok := d.subs[subscriptionID]; !ok || sub.Org != org {
+			return nil, ErrSubscriptionNotFound
+		}
+	}
+
+	result := make([]Delivery, 0)
+	for i := len(d.deliveries) - 1; i >= 0; i-- {
+		delivery := d.deliveries[i]
+		if delivery.Org != org {
+			continue
+		}
+		if subscriptionID == "" || delivery.SubscriptionID == subscriptionID {
+			result = append(result, delivery)
+		}
+	}
+	return result, nil
+}
+
+// Publish queues event for every matching subscription in org. It never
+// blocks: if the queue is full the delivery is dropped and logged.
+func (d *Dispatcher) Publish(org, event string, data any) {
+	d.mu.RLock()
+	targets := make([]Subscription, 0)
+	for _, sub := range d.subs {
+		if sub.Org == org && sub.wants(event) {
+			targets = append(targets, *sub)
+		}
+	}
+	d.mu.RUnlock()
+
+	occurredAt := d.cfg.Now().UTC().Format(time.RFC3339)
+	for _, sub := range targets {
+		id := newID()
+		body, err := json.Marshal(envelope{ID: id, Event: event, OccurredAt: occurredAt, Data: data})
+		if err != nil {
+			log.Printf("webhook: encode %s: %v", event, err)
+			continue
+		}
+
+		d.enqueue(job{id: id, sub: sub, event: event, body: body, signature: Sign(sub.Secret, body), attempt: 1})
+	}
+}
+
+// enqueue queues j without blocking; a full queue drops it.
+func (d *Dispatcher) enqueue(j job) {
+	select {
+	case d.queue <- j:
+	default:
+		d.record(Delivery{ID: j.id, Org: j.sub.Org, SubscriptionID: j.sub.ID, Event: j.event, Attempt: j.attempt, Error: "queue full", At: d.cfg.Now().UTC()})
+		log.Printf("webhook: queue full, dropped %s for %s", j.event, j.sub.ID)
+	}
+}
+
+// Run delivers queued events until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < d.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case j := <-d.queue:
+					d.deliver(ctx, j)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver makes one attempt at j. A failed attempt is queued again after its
+// backoff, so a worker never waits on a failing endpoint while deliveries to
+// healthy ones are pending.
+func (d *Dispatcher) deliver(ctx context.Context, j job) {
+	status, err := d.send(ctx, j)
+	delivery := Delivery{
+		ID:             j.id,
+		Org:            j.sub.Org,
+		SubscriptionID: j.sub.ID,
+		Event:          j.event,
+		Attempt:        j.attempt,
+		StatusCode:     status,
+		Success:        err == nil,
+		At:             d.cfg.Now().UTC(),
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	d.record(delivery)
+
+	if err == nil || j.attempt == d.cfg.MaxAttempts || ctx.Err() != nil {
+		return
+	}
+
+	retry := j
+	retry.attempt++
+	time.AfterFunc(d.backoff(j.attempt), func() {
+		if ctx.Err() == nil {
+			d.enqueue(retry)
+		}
+	})
+}
+
+func (d *Dispatcher) send(ctx context.Context, j job) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.sub.URL, bytes.NewReader(j.body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, j.event)
+	req.Header.Set(DeliveryHeader, j.id)
+	req.Header.Set(SignatureHeader, j.signature)
+
+	resp, err := d.cfg.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.cfg.BaseBackoff << (attempt - 1)
+	if delay <= 0 || delay > d.cfg.MaxBackoff {
+		return d.cfg.MaxBackoff
+	}
+	return delay
+}
+
+func (d *Dispatcher) record(delivery Delivery) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.deliveries = append(d.deliveries, delivery)
+	if over := len(d.deliveries) - d.cfg.LogSize; over > 0 {
+		d.deliveries = append(d.deliveries[:0], d.deliveries[over:]...)
+	}
+}
+
+func (s *Subscription) wants(event string) bool {
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns the signature header value for body: "sha256=" followed by
+// the hex HMAC-SHA256 of body keyed with secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type PullRequestData struct {
+	PullRequestID     string   `json:"pull_request_id"`
+	PullRequestName   string   `json:"pull_request_name"`
+	AuthorID          string   `json:"author_id"`
+	Status            string   `json:"status"`
+	AssignedReviewers []string `json:"assigned_reviewers"`
+	MergedAt          *string  `json:"merged_at,omitempty"`
+}
+
+type AssignedData struct {
+	PullRequest PullRequestData `json:"pull_request"`
+	Reviewers   []string        `json:"reviewers"`
+}
+
+type ReassignedData struct {
+	PullRequest   PullRequestData `json:"pull_request"`
+	OldReviewerID string          `json:"old_reviewer_id"`
+	NewReviewerID string          `json:"new_reviewer_id"`
+	Automatic     bool            `json:"automatic"`
+}
+
+type MergedData struct {
+	PullRequest PullRequestData `json:"pull_request"`
+}
+
+func NewPullRequestData(pr *store.PullRequest) PullRequestData {
+	data := PullRequestData{
+		PullRequestID:     pr.ID,
+		PullRequestName:   pr.Name,
+		AuthorID:          pr.AuthorID,
+		Status:            pr.Status,
+		AssignedReviewers: append([]string{}, pr.AssignedReviewers...),
+	}
+	if pr.MergedAt != nil {
+		merged := pr.MergedAt.Format(time.RFC3339)
+		data.MergedAt = &merged
+	}
+	return data
+}
+
+func newID() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,356 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// receiver records the requests it gets and answers with the statuses in
+// order, repeating the last one.
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedRequest
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	t.Helper()
+	rcv := &receiver{statuses: statuses}
+	rcv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rcv.mu.Lock()
+		status := rcv.statuses[min(len(rcv.requests), len(rcv.statuses)-1)]
+		rcv.requests = append(rcv.requests, receivedRequest{header: r.Header.Clone(), body: body})
+		rcv.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(rcv.Close)
+	return rcv
+}
+
+func (rcv *receiver) received() []receivedRequest {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	return append([]receivedRequest(nil), rcv.requests...)
+}
+
+func startDispatcher(t *testing.T, cfg Config) *Dispatcher {
+	t.Helper()
+	if cfg.BaseBackoff == 0 {
+		cfg.BaseBackoff = time.Millisecond
+	}
+	d := New(cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return d
+}
+
+// waitFor polls cond until it holds or the test times out.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		body   string
+		want   string
+	}{
+		{
+			name:   "known vector",
+			secret: "key",
+			body:   "The quick brown fox jumps over the lazy dog",
+			want:   "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8",
+		},
+		{
+			name:   "empty secret and body",
+			secret: "",
+			body:   "",
+			want:   "sha256=b613679a0814d9ec772f95d778c35fc5ff1697c493715653c6c712144292c5ad",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, []byte(tt.body)); got != tt.want {
+				t.Errorf("Sign() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeliverySignedHeaders(t *testing.T) {
+	rcv := newReceiver(t, http.StatusOK)
+	d := startDispatcher(t, Config{})
+
+	sub, err := d.Subscribe("acme", rcv.URL, []string{EventPullRequestMerged}, "s3cret")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	d.Publish("acme", EventPullRequestMerged, map[string]string{"pull_request_id": "pr-1"})
+
+	waitFor(t, "delivery", func() bool { return len(rcv.received()) == 1 })
+	req := rcv.received()[0]
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(req.body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := req.header.Get(SignatureHeader); got != want {
+		t.Errorf("%s = %s, want %s", SignatureHeader, got, want)
+	}
+	if got := req.header.Get(EventHeader); got != EventPullRequestMerged {
+		t.Errorf("%s = %s, want %s", EventHeader, got, EventPullRequestMerged)
+	}
+
+	waitFor(t, "delivery log", func() bool {
+		deliveries, _ := d.Deliveries("acme", sub.ID)
+		return len(deliveries) == 1
+	})
+	deliveries, _ := d.Deliveries("acme", sub.ID)
+	if got := req.header.Get(DeliveryHeader); got != deliveries[0].ID {
+		t.Errorf("%s = %s, want logged ID %s", DeliveryHeader, got, deliveries[0].ID)
+	}
+}
+
+func TestDeliveryRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantAttempts int
+		wantSuccess  bool
+	}{
+		{"success first time", []int{200}, 1, true},
+		{"recovers after 5xx", []int{500, 503, 200}, 3, true},
+		{"gives up after max attempts", []int{500}, 4, false},
+		{"4xx is retried too", []int{404, 204}, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rcv := newReceiver(t, tt.statuses...)
+			d := startDispatcher(t, Config{MaxAttempts: 4})
+
+			sub, err := d.Subscribe("acme", rcv.URL, nil, "")
+			if err != nil {
+				t.Fatalf("Subscribe: %v", err)
+			}
+			d.Publish("acme", EventReviewerAssigned, nil)
+
+			var deliveries []Delivery
+			waitFor(t, "final attempt", func() bool {
+				deliveries, _ = d.Deliveries("acme", sub.ID)
+				return len(deliveries) == tt.wantAttempts
+			})
+			// No attempt follows the last one.
+			time.Sleep(20 * time.Millisecond)
+			deliveries, _ = d.Deliveries("acme", sub.ID)
+			if len(deliveries) != tt.wantAttempts {
+				t.Fatalf("got %d attempts, want %d", len(deliveries), tt.wantAttempts)
+			}
+
+			// Deliveries are newest first.
+			for i, delivery := range deliveries {
+				attempt := tt.wantAttempts - i
+				if delivery.Attempt != attempt {
+					t.Errorf("deliveries[%d].Attempt = %d, want %d", i, delivery.Attempt, attempt)
+				}
+				if want := tt.statuses[min(attempt-1, len(tt.statuses)-1)]; delivery.StatusCode != want {
+					t.Errorf("attempt %d status = %d, want %d", attempt, delivery.StatusCode, want)
+				}
+				if delivery.ID != deliveries[0].ID {
+					t.Errorf("attempt %d has ID %s, want %s", attempt, delivery.ID, deliveries[0].ID)
+				}
+			}
+			if deliveries[0].Success != tt.wantSuccess {
+				t.Errorf("last attempt success = %v, want %v", deliveries[0].Success, tt.wantSuccess)
+			}
+		})
+	}
+}
+
+func TestFailingEndpointDoesNotBlockWorkers(t *testing.T) {
+	failing := newReceiver(t, http.StatusInternalServerError)
+	healthy := newReceiver(t, http.StatusOK)
+	d := startDispatcher(t, Config{Workers: 1, BaseBackoff: time.Hour, MaxBackoff: time.Hour})
+
+	if _, err := d.Subscribe("acme", failing.URL, nil, ""); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	d.Publish("acme", EventReviewerAssigned, nil)
+	waitFor(t, "failed attempt", func() bool { return len(failing.received()) == 1 })
+
+	if _, err := d.Subscribe("acme", healthy.URL, []string{EventPullRequestMerged}, ""); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	d.Publish("acme", EventPullRequestMerged, nil)
+	waitFor(t, "healthy delivery", func() bool { return len(healthy.received()) == 1 })
+}
+
+func TestDeliveriesScope(t *testing.T) {
+	rcv := newReceiver(t, http.StatusOK)
+	d := startDispatcher(t, Config{})
+
+	first, _ := d.Subscribe("acme", rcv.URL, nil, "")
+	second, _ := d.Subscribe("acme", rcv.URL, nil, "")
+	other, _ := d.Subscribe("globex", rcv.URL, nil, "")
+	d.Publish("acme", EventReviewerAssigned, nil)
+	d.Publish("globex", EventReviewerAssigned, nil)
+	waitFor(t, "delivery log", func() bool {
+		acme, _ := d.Deliveries("acme", "")
+		globex, _ := d.Deliveries("globex", "")
+		return len(acme)+len(globex) == 3
+	})
+
+	tests := []struct {
+		name    string
+		org     string
+		subID   string
+		want    int
+		wantErr error
+	}{
+		{"all of org", "acme", "", 2, nil},
+		{"one subscription", "acme", first.ID, 1, nil},
+		{"other subscription", "acme", second.ID, 1, nil},
+		{"other org", "globex", "", 1, nil},
+		{"subscription of another org", "acme", other.ID, 0, ErrSubscriptionNotFound},
+		{"unknown subscription", "acme", "missing", 0, ErrSubscriptionNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliveries, err := d.Deliveries(tt.org, tt.subID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(deliveries) != tt.want {
+				t.Errorf("got %d deliveries, want %d", len(deliveries), tt.want)
+			}
+			for _, delivery := range deliveries {
+				if delivery.Org != tt.org {
+					t.Errorf("delivery of org %s listed for %s", delivery.Org, tt.org)
+				}
+			}
+		})
+	}
+}
+
+func TestDeliveryLogSize(t *testing.T) {
+	rcv := newReceiver(t, http.StatusOK)
+	d := startDispatcher(t, Config{LogSize: 2})
+
+	sub, _ := d.Subscribe("acme", rcv.URL, nil, "")
+	for i := 0; i < 3; i++ {
+		d.Publish("acme", EventReviewerAssigned, nil)
+	}
+	waitFor(t, "deliveries", func() bool { return len(rcv.received()) == 3 })
+	waitFor(t, "log trimmed", func() bool {
+		deliveries, _ := d.Deliveries("acme", sub.ID)
+		return len(deliveries) == 2
+	})
+}
+
+func TestSubscriptionWants(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []string
+		event  string
+		want   bool
+	}{
+		{"subscribed event", []string{EventReviewerAssigned}, EventReviewerAssigned, true},
+		{"other event", []string{EventReviewerAssigned}, EventPullRequestMerged, false},
+		{"one of several", []string{EventPullRequestMerged, EventReviewerReassigned}, EventReviewerReassigned, true},
+		{"no events", nil, EventReviewerAssigned, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &Subscription{Events: tt.events}
+			if got := sub.wants(tt.event); got != tt.want {
+				t.Errorf("wants(%s) = %v, want %v", tt.event, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublishFiltersSubscriptions(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	d := New(Config{})
+	if _, err := d.Subscribe("acme", srv.URL, []string{EventPullRequestMerged}, ""); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	all, err := d.Subscribe("acme", srv.URL, nil, "")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if len(all.Events) != len(knownEvents) {
+		t.Errorf("subscription without events has %v, want every event", all.Events)
+	}
+
+	d.Publish("acme", EventReviewerAssigned, nil)
+	if got := len(d.queue); got != 1 {
+		t.Errorf("queued %d jobs for %s, want 1", got, EventReviewerAssigned)
+	}
+	d.Publish("globex", EventPullRequestMerged, nil)
+	if got := len(d.queue); got != 1 {
+		t.Errorf("queued %d jobs after publishing to another org, want 1", got)
+	}
+	if hits.Load() != 0 {
+		t.Errorf("delivered without Run")
+	}
+}
+
+func TestSubscribeValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		events  []string
+		wantErr error
+	}{
+		{"valid", "https://example.com/hook", nil, nil},
+		{"no scheme", "example.com/hook", nil, ErrInvalidURL},
+		{"ftp", "ftp://example.com", nil, ErrInvalidURL},
+		{"unknown event", "https://example.com", []string{"pr.opened"}, ErrUnknownEvent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(Config{}).Subscribe("acme", tt.url, tt.events, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}